@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dmitrykharchenko95/fibonacci/config"
+)
+
+const configUsage = `usage:
+  fibonacci config init [-format json|yaml|toml] [-o file] [-force]
+      generate a commented config with default values
+  fibonacci config validate [file]
+      check a config file (default: -config) without starting servers
+  fibonacci config print [-format json|yaml|toml]
+      print the effective configuration with secrets redacted
+`
+
+// runConfig выполняет подкоманду config с аргументами args и возвращает код завершения программы.
+func runConfig(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, configUsage)
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "init":
+		err = configInit(args[1:])
+	case "validate":
+		err = configValidate(args[1:])
+	case "print":
+		err = configPrint(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown config command %q\n%s", args[0], configUsage)
+		return 2
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+
+	if err != nil {
+		var verr *config.ValidationError
+		if errors.As(err, &verr) {
+			fmt.Fprintln(os.Stderr, "invalid config:")
+			for _, p := range verr.Problems {
+				fmt.Fprintln(os.Stderr, "  "+p)
+			}
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return 1
+	}
+	return 0
+}
+
+// configInit записывает конфигурацию со значениями по умолчанию и комментариями в файл или в stdout.
+func configInit(args []string) error {
+	fs := flag.NewFlagSet("config init", flag.ContinueOnError)
+	format := fs.String("format", "", "config format: json, yaml or toml (default: by -o extension or json)")
+	out := fs.String("o", "", "output file (default: stdout)")
+	force := fs.Bool("force", false, "overwrite an existing output file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *format == "" {
+		*format = formatByExt(*out)
+	}
+
+	if *out == "" {
+		return config.Encode(os.Stdout, config.Default(), *format, true)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if *force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+
+	f, err := os.OpenFile(*out, flags, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if err := config.Encode(f, config.Default(), *format, true); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// configValidate загружает и проверяет файл конфигурации так же, как при запуске сервиса.
+func configValidate(args []string) error {
+	file := configFile
+	switch len(args) {
+	case 0:
+	case 1:
+		file = args[0]
+	default:
+		return errors.New("config validate accepts a single file")
+	}
+
+	if _, err := config.New(file, overrides); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: config is valid\n", file)
+	return nil
+}
+
+// configPrint выводит итоговую конфигурацию с учетом файла, переменных окружения и флагов, скрывая секреты.
+func configPrint(args []string) error {
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	format := fs.String("format", config.FormatJSON, "output format: json, yaml or toml")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.New(configFile, overrides)
+	if err != nil {
+		return err
+	}
+
+	return config.Encode(os.Stdout, cfg.Redacted(), *format, false)
+}
+
+func formatByExt(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yml", ".yaml":
+		return config.FormatYAML
+	case ".toml":
+		return config.FormatTOML
+	}
+	return config.FormatJSON
+}
@@ -3,52 +3,144 @@ package main
 import (
 	"context"
 	"flag"
-	"log"
+	"log/slog"
+	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/dmitrykharchenko95/fibonacci/config"
+	"github.com/dmitrykharchenko95/fibonacci/internal/logger"
 	"github.com/dmitrykharchenko95/fibonacci/internal/server"
 	"github.com/dmitrykharchenko95/fibonacci/internal/service"
 )
 
-var configFile string
+var (
+	configFile  string
+	useRedis    bool
+	watchConfig time.Duration
+	overrides   = config.Overrides{}
+)
 
 func init() {
 	flag.StringVar(&configFile, "config", "./configs/fibonacci_config.json", "path to config file")
-	flag.BoolVar(&service.UseRedis, "redis", true, "using Redis for caching")
+	flag.BoolVar(&useRedis, "redis", true, "using Redis for caching")
+	flag.Var(overrides, "set", "override config parameter as key=value, e.g. -set http_timeout=30s (repeatable)")
+	flag.DurationVar(&watchConfig, "watch-config", 0, "config file check interval for hot reload (0 - disabled)")
 
 }
 
 func main() {
 	flag.Parse()
 
-	if flag.Arg(0) == "version" {
+	switch flag.Arg(0) {
+	case "version":
 		printVersion()
 		return
+	case "config":
+		os.Exit(runConfig(flag.Args()[1:]))
 	}
 
-	cfg, err := config.New(configFile)
+	cfg, err := config.New(configFile, overrides)
 	if err != nil {
-		log.Fatalf("config error: %v", err)
+		slog.Error("config error", "error", err)
+		os.Exit(1)
 	}
 
-	if cfg.Redis.MaxErrors == 0{
-		service.UseRedis = false
+	l, err := logger.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		slog.Error("logger error", "error", err)
+		os.Exit(1)
 	}
+	slog.SetDefault(l)
 
-	log.Printf("Fibonacci started. UseRaddis = %v", service.UseRedis)
+	service.SetUseRedis(useRedis && cfg.Redis.MaxErrors != 0)
 
-	s := server.New(cfg)
+	slog.Info("fibonacci started", "use_redis", service.UseRedis())
+
+	s, err := server.New(cfg)
+	if err != nil {
+		slog.Error("server error", "error", err)
+		os.Exit(1)
+	}
 
-	ctx, cancel := signal.NotifyContext(context.Background(),
-		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		for range hup {
+			reload(s)
+		}
+	}()
+
+	if watchConfig > 0 {
+		go watch(ctx, watchConfig, hup)
+	}
+
 	go func() {
 		<-ctx.Done()
 		s.Stop()
 	}()
 
-	s.Start()
+	if err := s.Start(); err != nil {
+		slog.Error("server error", "error", err)
+		os.Exit(1)
+	}
+}
+
+// reload перечитывает файл конфигурации и применяет изменения, не требующие перезапуска сервиса. Неверная конфигурация
+// отклоняется целиком.
+func reload(s *server.Sever) {
+	cfg, err := config.New(configFile, overrides)
+	if err != nil {
+		slog.Error("config reload rejected", "error", err)
+		return
+	}
+
+	restart, err := s.Reload(cfg)
+	if err != nil {
+		slog.Error("config reload rejected", "error", err)
+		return
+	}
+
+	if len(restart) > 0 {
+		slog.Warn("config changes require restart", "fields", restart)
+	}
+}
+
+// watch проверяет время изменения файла конфигурации с интервалом interval и при его изменении отправляет в reload
+// сигнал на перечитывание конфигурации.
+func watch(ctx context.Context, interval time.Duration, reload chan<- os.Signal) {
+	var modTime time.Time
+	if fi, err := os.Stat(configFile); err == nil {
+		modTime = fi.ModTime()
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			fi, err := os.Stat(configFile)
+			if err != nil {
+				slog.Error("config watch error", "error", err)
+				continue
+			}
+			if fi.ModTime().Equal(modTime) {
+				continue
+			}
+			modTime = fi.ModTime()
+
+			select {
+			case reload <- syscall.SIGHUP:
+			default:
+			}
+		}
+	}
 }
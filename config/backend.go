@@ -0,0 +1,303 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/heetch/confita"
+	"github.com/heetch/confita/backend"
+	"gopkg.in/yaml.v2"
+)
+
+// EnvPrefix - префикс переменных окружения, переопределяющих параметры конфигурации. Имя переменной состоит из префикса
+// и ключа параметра в верхнем регистре: например, FIBONACCI_HTTP_TIMEOUT для ключа http_timeout.
+const EnvPrefix = "FIBONACCI_"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// fileBackend загружает конфигурацию из файла форматов JSON, Yaml, Toml. В отличие от файлового бэкенда confita,
+// параметры типа time.Duration задаются в файле строками вида "10s", JSON может содержать комментарии "//", а
+// неизвестные параметры считаются ошибкой.
+type fileBackend struct {
+	path string
+}
+
+func (b fileBackend) Name() string {
+	return "file"
+}
+
+func (b fileBackend) Get(context.Context, string) ([]byte, error) {
+	return nil, backend.ErrNotFound
+}
+
+func (b fileBackend) Unmarshal(_ context.Context, to interface{}) error {
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+
+	var raw interface{}
+	switch ext := filepath.Ext(b.path); ext {
+	case ".json":
+		err = json.Unmarshal(stripComments(data), &raw)
+	case ".yml", ".yaml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		var m map[string]interface{}
+		err = toml.Unmarshal(data, &m)
+		raw = m
+	default:
+		err = fmt.Errorf("unsupported extension %q", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", b.path, err)
+	}
+
+	var problems []string
+	raw = normalize(raw, reflect.TypeOf(to).Elem(), "", &problems)
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	buf, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", b.path, err)
+	}
+
+	if err := json.NewDecoder(bytes.NewReader(buf)).Decode(to); err != nil {
+		return fmt.Errorf("config file %s: %w", b.path, err)
+	}
+	return nil
+}
+
+// stripComments удаляет из JSON комментарии, начинающиеся с "//" и продолжающиеся до конца строки. Строковые значения
+// не затрагиваются.
+func stripComments(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+
+	var inString, escaped bool
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i+1 < len(data) && data[i+1] != '\n' {
+				i++
+			}
+			continue
+		}
+
+		out = append(out, c)
+	}
+
+	return out
+}
+
+// normalize приводит значение v, декодированное из файла, к виду, пригодному для декодирования в тип t пакетом
+// encoding/json: ключи объектов становятся строками, а строки с длительностями - числом наносекунд. Неизвестные
+// параметры и неверные длительности добавляются в problems.
+func normalize(v interface{}, t reflect.Type, path string, problems *[]string) interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == durationType {
+		s, ok := v.(string)
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: should be a duration string like \"10s\"", path))
+			return v
+		}
+		if s == "" {
+			return 0
+		}
+
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			*problems = append(*problems, fmt.Sprintf("%s: invalid duration %q", path, s))
+			return v
+		}
+		return int64(d)
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := stringMap(v)
+		if !ok {
+			return v
+		}
+
+		out := make(map[string]interface{}, len(m))
+		for k, fv := range m {
+			f, ok := fieldByName(t, k)
+			if !ok {
+				*problems = append(*problems, fmt.Sprintf("%s: unknown parameter", joinPath(path, k)))
+				continue
+			}
+			out[f.Name] = normalize(fv, f.Type, joinPath(path, f.Name), problems)
+		}
+		return out
+	case reflect.Map:
+		m, ok := stringMap(v)
+		if !ok {
+			return v
+		}
+
+		out := make(map[string]interface{}, len(m))
+		for k, mv := range m {
+			out[k] = normalize(mv, t.Elem(), joinPath(path, k), problems)
+		}
+		return out
+	case reflect.Slice:
+		s, ok := v.([]interface{})
+		if !ok {
+			return v
+		}
+
+		out := make([]interface{}, len(s))
+		for i, sv := range s {
+			out[i] = normalize(sv, t.Elem(), fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+		return out
+	}
+
+	return v
+}
+
+// stringMap приводит объект, декодированный из JSON, Yaml или Toml, к map[string]interface{}.
+func stringMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(m))
+		for k, mv := range m {
+			out[fmt.Sprint(k)] = mv
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// fieldByName ищет экспортируемое поле структуры t по имени name без учета регистра, как это делает encoding/json.
+func fieldByName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath == "" && strings.EqualFold(f.Name, name) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// envBackend переопределяет параметры конфигурации значениями переменных окружения с префиксом EnvPrefix.
+type envBackend struct{}
+
+func (envBackend) Name() string {
+	return "env"
+}
+
+func (envBackend) Get(context.Context, string) ([]byte, error) {
+	return nil, backend.ErrNotFound
+}
+
+func (envBackend) LoadStruct(_ context.Context, cfg *confita.StructConfig) error {
+	var problems []string
+	for _, f := range cfg.Fields {
+		name := EnvPrefix + strings.ToUpper(f.Key)
+		if val, ok := os.LookupEnv(name); ok {
+			if err := f.Set(val); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid value %q: %v", name, val, err))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Overrides - значения параметров конфигурации, заданные флагами командной строки в виде key=value, где key - ключ
+// параметра (например, http_timeout). Overrides реализует flag.Value, поэтому флаг можно указывать несколько раз.
+type Overrides map[string]string
+
+func (o Overrides) String() string {
+	pairs := make([]string, 0, len(o))
+	for k, v := range o {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (o Overrides) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || k == "" {
+		return errors.New("should be in key=value format")
+	}
+
+	o[k] = v
+	return nil
+}
+
+func (o Overrides) Name() string {
+	return "flags"
+}
+
+func (o Overrides) Get(context.Context, string) ([]byte, error) {
+	return nil, backend.ErrNotFound
+}
+
+func (o Overrides) LoadStruct(_ context.Context, cfg *confita.StructConfig) error {
+	var problems []string
+
+	known := make(map[string]bool, len(cfg.Fields))
+	for _, f := range cfg.Fields {
+		known[f.Key] = true
+		if val, ok := o[f.Key]; ok {
+			if err := f.Set(val); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid value %q: %v", f.Key, val, err))
+			}
+		}
+	}
+
+	for k := range o {
+		if !known[k] {
+			problems = append(problems, fmt.Sprintf("%s: unknown parameter", k))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
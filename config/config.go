@@ -2,44 +2,285 @@ package config
 
 import (
 	"context"
+	"net"
+	"time"
 
 	"github.com/heetch/confita"
-	"github.com/heetch/confita/backend/file"
 )
 
 type Config struct {
-	HTTP  HTTPConfig
-	GRPC  GRPCConfig
-	Redis RedisConfig
+	HTTP      HTTPConfig
+	GRPC      GRPCConfig
+	Redis     RedisConfig
+	Scheduler SchedulerConfig
+	RateLimit RateLimitConfig
+	Auth      AuthConfig
+	Quota     QuotaConfig
+	Metrics   MetricsConfig
+	Tracing   TracingConfig
+	Log       LogConfig
+	Health    HealthConfig
+	Admin     AdminConfig
+	Shutdown  ShutdownConfig
 }
 
+// HTTPConfig задает параметры HTTP сервера. Сервер прослушивает адреса Listen (TCP, Unix сокеты и сокеты systemd, см.
+// listener.Parse), а если они не заданы - Host:Port. При заданных CertFile и KeyFile сервер принимает HTTPS соединения,
+// при заданном CAFile - проверяет клиентские сертификаты, а при ClientCertRequired - требует их (mTLS).
 type HTTPConfig struct {
-	Host    string        `config:"http_host"`
-	Port    string        `config:"http_port"`
-	Timeout string `config:"http_timeout"`
+	Enabled            bool          `config:"http_enabled"`
+	Host               string        `config:"http_host"`
+	Port               string        `config:"http_port"`
+	Listen             []string      `config:"http_listen"`
+	Timeout            time.Duration `config:"http_timeout"`
+	MaxPageSize        int           `config:"http_max_page_size"`
+	CertFile           string        `config:"http_cert_file"`
+	KeyFile            string        `config:"http_key_file"`
+	CAFile             string        `config:"http_ca_file"`
+	ClientCertRequired bool          `config:"http_client_cert_required"`
+	Gateway            bool          `config:"http_gateway"`
 }
 
+// GRPCConfig задает параметры gRPC сервера. Адреса и параметры TLS аналогичны параметрам HTTPConfig.
 type GRPCConfig struct {
-	Host    string        `config:"grpc_host"`
-	Port    string        `config:"grpc_port"`
-	Timeout string `config:"grpc_timeout"`
+	Enabled            bool          `config:"grpc_enabled"`
+	Host               string        `config:"grpc_host"`
+	Port               string        `config:"grpc_port"`
+	Listen             []string      `config:"grpc_listen"`
+	Timeout            time.Duration `config:"grpc_timeout"`
+	MaxPageSize        int           `config:"grpc_max_page_size"`
+	CertFile           string        `config:"grpc_cert_file"`
+	KeyFile            string        `config:"grpc_key_file"`
+	CAFile             string        `config:"grpc_ca_file"`
+	ClientCertRequired bool          `config:"grpc_client_cert_required"`
+	Reflection         bool          `config:"grpc_reflection"`
+	Web                bool          `config:"grpc_web"`
+	WebOrigins         []string      `config:"grpc_web_origins"`
 }
 
 type RedisConfig struct {
 	Host       string        `config:"redis_host"`
 	Port       string        `config:"redis_port"`
-	Expiration string `config:"redis_expiration"`
+	Expiration time.Duration `config:"redis_expiration"`
 	MaxErrors  int           `config:"redis_max_errors"`
 }
 
-func New(configFile string) (*Config, error) {
-	cfg := &Config{}
+type SchedulerConfig struct {
+	Workers   int `config:"scheduler_workers"`
+	QueueSize int `config:"scheduler_queue_size"`
+}
+
+// RateLimitConfig задает ограничение частоты запросов клиентов. Rate и Burst применяются ко всем эндпоинтам, для
+// которых не задан собственный лимит в Endpoints. Ключами Endpoints являются пути HTTP запросов ("/") и полные имена
+// gRPC методов ("/pb.fibonacci/getFibonacci").
+type RateLimitConfig struct {
+	Enabled   bool    `config:"ratelimit_enabled"`
+	UseRedis  bool    `config:"ratelimit_use_redis"`
+	KeyHeader string  `config:"ratelimit_key_header"`
+	Rate      float64 `config:"ratelimit_rate"`
+	Burst     int     `config:"ratelimit_burst"`
+	Endpoints map[string]LimitConfig
+}
 
-	l := confita.NewLoader(file.NewBackend(configFile))
+type LimitConfig struct {
+	Rate  float64
+	Burst int
+}
+
+// AuthConfig задает аутентификацию клиентов. Клиенты передают API ключ в заголовке APIKeyHeader или JWT в заголовке
+// Authorization. Запросы чисел с порядковыми номерами больше HugeIndex требуют области доступа "fibonacci:huge".
+type AuthConfig struct {
+	Enabled        bool   `config:"auth_enabled"`
+	AllowAnonymous bool   `config:"auth_allow_anonymous"`
+	APIKeyHeader   string `config:"auth_api_key_header"`
+	HugeIndex      int    `config:"auth_huge_index"`
+	APIKeys        []APIKeyConfig
+	JWT            JWTConfig
+}
+
+type APIKeyConfig struct {
+	Key    string
+	Name   string
+	Scopes []string
+}
+
+type JWTConfig struct {
+	HMACSecret   string `config:"auth_jwt_hmac_secret"`
+	RSAPublicKey string `config:"auth_jwt_rsa_public_key"`
+	Issuer       string `config:"auth_jwt_issuer"`
+	Audience     string `config:"auth_jwt_audience"`
+}
+
+// QuotaConfig задает суточные и месячные квоты клиентов на время вычислений и объем отправленных данных. Квоты Default
+// применяются ко всем клиентам, для которых не заданы собственные квоты в Clients. Ключами Clients являются имена
+// аутентифицированных клиентов. Потребление хранится в Redis (UseRedis) или в файле StoreFile.
+type QuotaConfig struct {
+	Enabled   bool   `config:"quota_enabled"`
+	UseRedis  bool   `config:"quota_use_redis"`
+	StoreFile string `config:"quota_store_file"`
+	Default   QuotaLimitsConfig
+	Clients   map[string]QuotaLimitsConfig
+}
+
+type QuotaLimitsConfig struct {
+	DailyComputeTime   time.Duration
+	MonthlyComputeTime time.Duration
+	DailyBytes         int64
+	MonthlyBytes       int64
+}
+
+// MetricsConfig задает эндпоинт HTTP сервера, отдающий метрики в формате Prometheus.
+type MetricsConfig struct {
+	Enabled bool   `config:"metrics_enabled"`
+	Path    string `config:"metrics_path"`
+}
+
+// TracingConfig задает экспорт спанов по протоколу OTLP/HTTP. Endpoint - адрес коллектора в формате host:port,
+// SampleRatio - доля трассируемых запросов без родительского спана.
+type TracingConfig struct {
+	Enabled     bool    `config:"tracing_enabled"`
+	Endpoint    string  `config:"tracing_endpoint"`
+	Insecure    bool    `config:"tracing_insecure"`
+	ServiceName string  `config:"tracing_service_name"`
+	SampleRatio float64 `config:"tracing_sample_ratio"`
+}
+
+// LogConfig задает параметры логирования. Level - минимальный уровень записей (debug, info, warn, error), Format -
+// формат записей (json или logfmt).
+type LogConfig struct {
+	Level  string `config:"log_level"`
+	Format string `config:"log_format"`
+}
+
+// HealthConfig задает проверки здоровья сервиса. LivenessPath и ReadinessPath - пути HTTP эндпоинтов проверок живости и
+// готовности, CheckTimeout - максимальное время выполнения каждой проверки. При RedisCheck в проверку готовности входят
+// доступность Redis и состояние его отключения после ошибок, а при RequireRedis их неудача делает сервис не готовым.
+// WarmUpIndex - наибольший порядковый номер чисел, вычисляемых (и кэшируемых в Redis) при старте до готовности сервиса.
+type HealthConfig struct {
+	LivenessPath  string        `config:"health_liveness_path"`
+	ReadinessPath string        `config:"health_readiness_path"`
+	CheckTimeout  time.Duration `config:"health_check_timeout"`
+	RedisCheck    bool          `config:"health_redis_check"`
+	RequireRedis  bool          `config:"health_require_redis"`
+	WarmUpIndex   int           `config:"health_warm_up_index"`
+}
+
+// AdminConfig задает параметры служебного HTTP сервера с профилированием, статистикой среды выполнения и
+// переключателями параметров сервиса. Сервер следует делать доступным только из внутренней сети.
+type AdminConfig struct {
+	Enabled bool   `config:"admin_enabled"`
+	Host    string `config:"admin_host"`
+	Port    string `config:"admin_port"`
+}
+
+// ShutdownConfig задает параметры остановки сервиса. После начала остановки сервис перестает быть готовым и через
+// DrainDelay прекращает прием новых запросов. GracePeriod - максимальное время ожидания завершения обрабатываемых
+// запросов и записей в Redis, после которого соединения закрываются принудительно.
+type ShutdownConfig struct {
+	DrainDelay  time.Duration `config:"shutdown_drain_delay"`
+	GracePeriod time.Duration `config:"shutdown_grace_period"`
+}
+
+// Default возвращает конфигурацию со значениями по умолчанию, поверх которых New применяет значения из файла,
+// переменных окружения и флагов.
+func Default() *Config {
+	return &Config{
+		HTTP: HTTPConfig{
+			Enabled:     true,
+			Host:        "0.0.0.0",
+			Port:        "8080",
+			Listen:      []string{},
+			Timeout:     10 * time.Second,
+			MaxPageSize: 10000,
+		},
+		GRPC: GRPCConfig{
+			Enabled:     true,
+			Host:        "0.0.0.0",
+			Port:        "50052",
+			Listen:      []string{},
+			Timeout:     10 * time.Second,
+			MaxPageSize: 10000,
+			WebOrigins:  []string{},
+		},
+		Redis: RedisConfig{
+			Host:       "localhost",
+			Port:       "6379",
+			Expiration: 12 * time.Hour,
+		},
+		Scheduler: SchedulerConfig{
+			QueueSize: 100,
+		},
+		RateLimit: RateLimitConfig{
+			KeyHeader: "X-API-Key",
+			Endpoints: map[string]LimitConfig{},
+		},
+		Auth: AuthConfig{
+			APIKeyHeader: "X-API-Key",
+			APIKeys:      []APIKeyConfig{},
+		},
+		Quota: QuotaConfig{
+			StoreFile: "./quota.json",
+			Clients:   map[string]QuotaLimitsConfig{},
+		},
+		Metrics: MetricsConfig{
+			Path: "/metrics",
+		},
+		Tracing: TracingConfig{
+			Endpoint:    "localhost:4318",
+			ServiceName: "fibonacci",
+			SampleRatio: 1,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Health: HealthConfig{
+			LivenessPath:  "/healthz",
+			ReadinessPath: "/readyz",
+			CheckTimeout:  time.Second,
+		},
+		Admin: AdminConfig{
+			Host: "127.0.0.1",
+			Port: "6060",
+		},
+		Shutdown: ShutdownConfig{
+			GracePeriod: 30 * time.Second,
+		},
+	}
+}
+
+// New загружает конфигурацию. Значения по умолчанию (Default) переопределяются значениями из файла configFile, затем
+// переменными окружения с префиксом EnvPrefix и, наконец, значениями overrides, заданными флагами командной строки.
+// Загруженная конфигурация проверяется методом Validate.
+func New(configFile string, overrides Overrides) (*Config, error) {
+	cfg := Default()
+
+	l := confita.NewLoader(fileBackend{path: configFile}, envBackend{}, overrides)
 
 	if err := l.Load(context.Background(), cfg); err != nil {
 		return nil, err
 	}
 
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
+
+// Addrs возвращает адреса, которые прослушивает HTTP сервер.
+func (c HTTPConfig) Addrs() []string {
+	return listenAddrs(c.Listen, c.Host, c.Port)
+}
+
+// Addrs возвращает адреса, которые прослушивает gRPC сервер.
+func (c GRPCConfig) Addrs() []string {
+	return listenAddrs(c.Listen, c.Host, c.Port)
+}
+
+func listenAddrs(listen []string, host, port string) []string {
+	if len(listen) > 0 {
+		return listen
+	}
+	return []string{net.JoinHostPort(host, port)}
+}
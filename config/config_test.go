@@ -1,7 +1,12 @@
 package config
 
 import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -10,26 +15,208 @@ func TestNewConfig(t *testing.T) {
 
 	want := &Config{
 		HTTP: HTTPConfig{
-			Host:    "0.0.0.0",
-			Port:    "8080",
-			Timeout: "10s",
+			Enabled:     true,
+			Host:        "0.0.0.0",
+			Port:        "8080",
+			Listen:      []string{},
+			Timeout:     10 * time.Second,
+			MaxPageSize: 10000,
+			Gateway:     true,
 		},
 		GRPC: GRPCConfig{
-			Host:    "0.0.0.0",
-			Port:    "50052",
-			Timeout: "10s",
+			Enabled:     true,
+			Host:        "0.0.0.0",
+			Port:        "50052",
+			Listen:      []string{},
+			Timeout:     10 * time.Second,
+			MaxPageSize: 10000,
+			Reflection:  true,
+			Web:         true,
+			WebOrigins:  []string{},
 		},
 		Redis: RedisConfig{
 			Host:       "0.0.0.0",
 			Port:       "6379",
-			Expiration: "12h",
+			Expiration: 12 * time.Hour,
 			MaxErrors:  6,
 		},
+		Scheduler: SchedulerConfig{
+			Workers:   4,
+			QueueSize: 100,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:   false,
+			UseRedis:  false,
+			KeyHeader: "X-API-Key",
+			Rate:      10,
+			Burst:     20,
+			Endpoints: map[string]LimitConfig{
+				"/":                          {Rate: 10, Burst: 20},
+				"/pb.fibonacci/getFibonacci": {Rate: 10, Burst: 20},
+			},
+		},
+		Auth: AuthConfig{
+			Enabled:        false,
+			AllowAnonymous: true,
+			APIKeyHeader:   "X-API-Key",
+			HugeIndex:      100000,
+			APIKeys:        []APIKeyConfig{},
+		},
+		Quota: QuotaConfig{
+			Enabled:   false,
+			UseRedis:  false,
+			StoreFile: "./quota.json",
+			Default: QuotaLimitsConfig{
+				DailyComputeTime:   10 * time.Minute,
+				MonthlyComputeTime: 100 * time.Hour,
+				DailyBytes:         1073741824,
+			},
+			Clients: map[string]QuotaLimitsConfig{
+				"example": {DailyComputeTime: time.Hour},
+			},
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Path:    "/metrics",
+		},
+		Tracing: TracingConfig{
+			Enabled:     false,
+			Endpoint:    "localhost:4318",
+			Insecure:    true,
+			ServiceName: "fibonacci",
+			SampleRatio: 1,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Health: HealthConfig{
+			LivenessPath:  "/healthz",
+			ReadinessPath: "/readyz",
+			CheckTimeout:  time.Second,
+			RedisCheck:    true,
+			RequireRedis:  false,
+			WarmUpIndex:   0,
+		},
+		Admin: AdminConfig{
+			Enabled: false,
+			Host:    "127.0.0.1",
+			Port:    "6060",
+		},
+		Shutdown: ShutdownConfig{
+			DrainDelay:  0,
+			GracePeriod: 30 * time.Second,
+		},
 	}
 
 	t.Run("base", func(t *testing.T) {
-		got, err := New("../configs/fibonacci_config.json")
+		got, err := New("../configs/fibonacci_config.json", nil)
 		require.NoError(t, err)
 		require.Equal(t, got, want)
 	})
+
+	t.Run("defaults", func(t *testing.T) {
+		got, err := New(writeConfig(t, "config.yaml", "HTTP:\n  Port: \"9090\"\n"), nil)
+		require.NoError(t, err)
+
+		def := Default()
+		def.HTTP.Port = "9090"
+		require.Equal(t, def, got)
+	})
+
+	t.Run("env and flags override file", func(t *testing.T) {
+		t.Setenv("FIBONACCI_HTTP_TIMEOUT", "20s")
+		t.Setenv("FIBONACCI_GRPC_TIMEOUT", "20s")
+		t.Setenv("FIBONACCI_LOG_LEVEL", "debug")
+
+		got, err := New("../configs/fibonacci_config.json", Overrides{"grpc_timeout": "30s", "redis_max_errors": "0"})
+		require.NoError(t, err)
+		require.Equal(t, 20*time.Second, got.HTTP.Timeout)
+		require.Equal(t, 30*time.Second, got.GRPC.Timeout)
+		require.Equal(t, "debug", got.Log.Level)
+		require.Equal(t, 0, got.Redis.MaxErrors)
+	})
+
+	t.Run("invalid overrides", func(t *testing.T) {
+		t.Setenv("FIBONACCI_HTTP_TIMEOUT", "soon")
+
+		_, err := New("../configs/fibonacci_config.json", nil)
+		require.EqualError(t, err, `invalid config: FIBONACCI_HTTP_TIMEOUT: invalid value "soon": `+
+			`time: invalid duration "soon"`)
+
+		os.Unsetenv("FIBONACCI_HTTP_TIMEOUT")
+		_, err = New("../configs/fibonacci_config.json", Overrides{"http_timeuot": "1s"})
+		require.EqualError(t, err, "invalid config: http_timeuot: unknown parameter")
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		file := writeConfig(t, "config.json",
+			`{"HTTP": {"Timeout": "soon"}, "GRPC": {"Timeuot": "1s"}, "Quota": {"Clients": {"a": {"DailyComputeTime": 5}}}}`)
+
+		_, err := New(file, nil)
+		require.Error(t, err)
+
+		var verr *ValidationError
+		require.ErrorAs(t, err, &verr)
+		require.ElementsMatch(t, []string{
+			`HTTP.Timeout: invalid duration "soon"`,
+			"GRPC.Timeuot: unknown parameter",
+			`Quota.Clients.a.DailyComputeTime: should be a duration string like "10s"`,
+		}, verr.Problems)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		file := writeConfig(t, "config.toml", `
+[HTTP]
+Port = ""
+Timeout = "-1s"
+
+[Log]
+Level = "verbose"
+
+[Tracing]
+SampleRatio = 2.0
+`)
+
+		_, err := New(file, nil)
+		require.EqualError(t, err, "invalid config: HTTP.Port: should be set; HTTP.Timeout: should be positive; "+
+			"Log.Level: should be one of debug, info, warn, error; Tracing.SampleRatio: should be between 0 and 1")
+	})
+}
+
+func TestEncode(t *testing.T) {
+	base, err := New("../configs/fibonacci_config.json", nil)
+	require.NoError(t, err)
+	base.Auth.JWT.HMACSecret = "secret"
+	base.Auth.APIKeys = []APIKeyConfig{{Key: "change-me", Name: "example", Scopes: []string{"fibonacci:huge"}}}
+
+	for _, format := range []string{FormatJSON, FormatYAML, FormatTOML} {
+		for _, cfg := range []*Config{Default(), base} {
+			var buf bytes.Buffer
+			require.NoError(t, Encode(&buf, cfg, format, true))
+
+			got, err := New(writeConfig(t, "config."+format, buf.String()), nil)
+			require.NoError(t, err, buf.String())
+			require.Equal(t, cfg, got, format)
+		}
+	}
+
+	t.Run("redacted", func(t *testing.T) {
+		r := base.Redacted()
+		require.Equal(t, "REDACTED", r.Auth.JWT.HMACSecret)
+		require.Equal(t, "REDACTED", r.Auth.APIKeys[0].Key)
+		require.Equal(t, "change-me", base.Auth.APIKeys[0].Key)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		require.Error(t, Encode(io.Discard, base, "ini", false))
+	})
+}
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+	return file
 }
@@ -0,0 +1,300 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Форматы файла конфигурации.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// redacted заменяет значения секретов в выводе конфигурации.
+const redacted = "REDACTED"
+
+// descriptions - описания параметров, выводимые Encode в комментариях.
+var descriptions = map[string]string{
+	"HTTP":                             "HTTP server",
+	"HTTP.Enabled":                     "enable the HTTP server",
+	"HTTP.Host":                        "host to listen on",
+	"HTTP.Port":                        "port to listen on",
+	"HTTP.Listen":                      "listen addresses: host:port, tcp://host:port, unix:///path or systemd:name; default Host:Port",
+	"HTTP.Timeout":                     "Fibonacci computation timeout",
+	"HTTP.MaxPageSize":                 "maximum number of Fibonacci numbers in a page of a range",
+	"HTTP.CertFile":                    "PEM server certificate; enables HTTPS together with KeyFile",
+	"HTTP.KeyFile":                     "PEM server private key",
+	"HTTP.CAFile":                      "PEM CA certificates used to verify client certificates",
+	"HTTP.ClientCertRequired":          "require client certificates (mTLS); requires CAFile",
+	"HTTP.Gateway":                     "serve the HTTP/JSON gateway to the gRPC services under /rpc/; requires GRPC.Enabled",
+	"GRPC":                             "gRPC server",
+	"GRPC.Enabled":                     "enable the gRPC server",
+	"GRPC.Host":                        "host to listen on",
+	"GRPC.Port":                        "port to listen on",
+	"GRPC.Listen":                      "listen addresses: host:port, tcp://host:port, unix:///path or systemd:name; default Host:Port",
+	"GRPC.Timeout":                     "Fibonacci computation timeout",
+	"GRPC.MaxPageSize":                 "maximum number of Fibonacci numbers in a page of a range",
+	"GRPC.CertFile":                    "PEM server certificate; enables TLS together with KeyFile",
+	"GRPC.KeyFile":                     "PEM server private key",
+	"GRPC.CAFile":                      "PEM CA certificates used to verify client certificates",
+	"GRPC.ClientCertRequired":          "require client certificates (mTLS); requires CAFile",
+	"GRPC.Reflection":                  "register the gRPC server reflection service",
+	"GRPC.Web":                         "serve gRPC-Web requests on the HTTP server; requires HTTP.Enabled",
+	"GRPC.WebOrigins":                  "origins allowed to make cross-origin gRPC-Web requests; \"*\" allows any origin",
+	"Redis":                            "Redis cache",
+	"Redis.Host":                       "Redis host",
+	"Redis.Port":                       "Redis port",
+	"Redis.Expiration":                 "time to keep cached numbers",
+	"Redis.MaxErrors":                  "Redis errors before caching is disabled; 0 disables Redis",
+	"Scheduler":                        "computation scheduler",
+	"Scheduler.Workers":                "concurrent computations; 0 means the number of CPUs",
+	"Scheduler.QueueSize":              "requests waiting for a free worker before load shedding",
+	"RateLimit":                        "per-client rate limiting",
+	"RateLimit.Enabled":                "enable rate limiting",
+	"RateLimit.UseRedis":               "share limits between instances through Redis",
+	"RateLimit.KeyHeader":              "header (metadata) carrying the client API key",
+	"RateLimit.Rate":                   "requests per second per client and endpoint",
+	"RateLimit.Burst":                  "maximum burst of requests",
+	"RateLimit.Endpoints":              "per-endpoint Rate and Burst keyed by HTTP route pattern or full gRPC method",
+	"Auth":                             "client authentication",
+	"Auth.Enabled":                     "enable authentication",
+	"Auth.AllowAnonymous":              "accept requests without credentials",
+	"Auth.APIKeyHeader":                "header (metadata) carrying the client API key",
+	"Auth.HugeIndex":                   "indexes above this require the fibonacci:huge scope; 0 disables the check",
+	"Auth.APIKeys":                     "static API keys: Key, Name, Scopes",
+	"Auth.JWT":                         "bearer JWT verification",
+	"Auth.JWT.HMACSecret":              "secret for HS256/384/512",
+	"Auth.JWT.RSAPublicKey":            "PEM public key file for RS256/384/512",
+	"Auth.JWT.Issuer":                  "expected iss claim",
+	"Auth.JWT.Audience":                "expected aud claim",
+	"Quota":                            "per-client daily and monthly quotas",
+	"Quota.Enabled":                    "enable quotas",
+	"Quota.UseRedis":                   "keep usage in Redis",
+	"Quota.StoreFile":                  "file keeping usage when UseRedis is false",
+	"Quota.Default":                    "quotas of clients without their own; zero means unlimited",
+	"Quota.Default.DailyComputeTime":   "daily computation time",
+	"Quota.Default.MonthlyComputeTime": "monthly computation time",
+	"Quota.Default.DailyBytes":         "daily response bytes",
+	"Quota.Default.MonthlyBytes":       "monthly response bytes",
+	"Quota.Clients":                    "per-client quotas keyed by client name",
+	"Metrics":                          "Prometheus metrics",
+	"Metrics.Enabled":                  "serve metrics on the HTTP server",
+	"Metrics.Path":                     "metrics endpoint path",
+	"Tracing":                          "OpenTelemetry tracing",
+	"Tracing.Enabled":                  "export spans",
+	"Tracing.Endpoint":                 "OTLP/HTTP collector host:port",
+	"Tracing.Insecure":                 "export without TLS",
+	"Tracing.ServiceName":              "service name in spans",
+	"Tracing.SampleRatio":              "fraction of root requests to trace, 0 to 1",
+	"Log":                              "logging",
+	"Log.Level":                        "debug, info, warn or error",
+	"Log.Format":                       "json or logfmt",
+	"Health":                           "health checks",
+	"Health.LivenessPath":              "liveness endpoint path",
+	"Health.ReadinessPath":             "readiness endpoint path",
+	"Health.CheckTimeout":              "timeout of each check",
+	"Health.RedisCheck":                "include Redis in the readiness check",
+	"Health.RequireRedis":              "report not ready when Redis is unavailable",
+	"Health.WarmUpIndex":               "largest index computed on start before ready; 0 disables warm-up",
+	"Admin":                            "admin server with pprof, runtime stats and toggles",
+	"Admin.Enabled":                    "enable the admin server",
+	"Admin.Host":                       "host to listen on; keep it internal",
+	"Admin.Port":                       "port to listen on",
+	"Shutdown":                         "graceful shutdown",
+	"Shutdown.DrainDelay":              "delay between failing readiness and closing listeners",
+	"Shutdown.GracePeriod":             "maximum wait for in-flight requests and Redis writes",
+}
+
+// Redacted возвращает копию конфигурации, в которой значения секретов заменены на "REDACTED".
+func (c *Config) Redacted() *Config {
+	r := *c
+
+	r.Auth.APIKeys = make([]APIKeyConfig, len(c.Auth.APIKeys))
+	for i, k := range c.Auth.APIKeys {
+		k.Key = redacted
+		r.Auth.APIKeys[i] = k
+	}
+
+	if r.Auth.JWT.HMACSecret != "" {
+		r.Auth.JWT.HMACSecret = redacted
+	}
+
+	return &r
+}
+
+// Encode записывает конфигурацию cfg в w в формате format (json, yaml или toml). При comments перед параметрами
+// выводятся комментарии с их описанием. Результат читается New; комментарии в JSON задаются строками "//".
+func Encode(w io.Writer, cfg *Config, format string, comments bool) error {
+	e := &encoder{w: bufio.NewWriter(w), format: strings.ToLower(format), comments: comments}
+
+	v := reflect.ValueOf(*cfg)
+	switch e.format {
+	case FormatJSON:
+		e.jsonObject(v, "", 0)
+		e.line(0, "")
+	case FormatYAML:
+		e.yamlObject(v, "", 0)
+	case FormatTOML:
+		e.tomlTable(v, "")
+	default:
+		return fmt.Errorf("unknown config format %q", format)
+	}
+
+	return e.w.Flush()
+}
+
+type encoder struct {
+	w        *bufio.Writer
+	format   string
+	comments bool
+}
+
+func (e *encoder) line(depth int, format string, args ...interface{}) {
+	e.w.WriteString(strings.Repeat("  ", depth))
+	fmt.Fprintf(e.w, format, args...)
+	e.w.WriteByte('\n')
+}
+
+func (e *encoder) comment(depth int, marker, path string) {
+	if !e.comments {
+		return
+	}
+	if d, ok := descriptions[path]; ok {
+		e.line(depth, "%s %s", marker, d)
+	}
+}
+
+func (e *encoder) jsonObject(v reflect.Value, path string, depth int) {
+	e.w.WriteString("{\n")
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		fpath := joinPath(path, name)
+		sep := ","
+		if i == v.NumField()-1 {
+			sep = ""
+		}
+
+		e.comment(depth+1, "//", fpath)
+		e.w.WriteString(strings.Repeat("  ", depth+1) + strconv.Quote(name) + ": ")
+		if f := v.Field(i); f.Kind() == reflect.Struct {
+			e.jsonObject(f, fpath, depth+1)
+			e.w.WriteString(sep + "\n")
+		} else {
+			e.w.WriteString(e.inline(f) + sep + "\n")
+		}
+	}
+	e.w.WriteString(strings.Repeat("  ", depth) + "}")
+}
+
+func (e *encoder) yamlObject(v reflect.Value, path string, depth int) {
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		fpath := joinPath(path, name)
+
+		e.comment(depth, "#", fpath)
+		if f := v.Field(i); f.Kind() == reflect.Struct {
+			e.line(depth, "%s:", name)
+			e.yamlObject(f, fpath, depth+1)
+		} else {
+			e.line(depth, "%s: %s", name, e.inline(f))
+		}
+	}
+}
+
+// tomlTable выводит поля структуры v таблицей path. Вложенные структуры выводятся отдельными таблицами после
+// остальных полей, как того требует формат TOML.
+func (e *encoder) tomlTable(v reflect.Value, path string) {
+	if path != "" {
+		e.comment(0, "#", path)
+		e.line(0, "[%s]", path)
+	}
+
+	var tables []int
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		if v.Field(i).Kind() == reflect.Struct {
+			tables = append(tables, i)
+			continue
+		}
+
+		e.comment(0, "#", joinPath(path, name))
+		e.line(0, "%s = %s", name, e.inline(v.Field(i)))
+	}
+
+	for _, i := range tables {
+		if path != "" || i != tables[0] {
+			e.line(0, "")
+		}
+		e.tomlTable(v.Field(i), joinPath(path, v.Type().Field(i).Name))
+	}
+}
+
+// inline возвращает значение v в одну строку. Объекты выводятся в синтаксисе JSON (YAML понимает его как flow style)
+// или встроенными таблицами TOML.
+func (e *encoder) inline(v reflect.Value) string {
+	if v.Type() == durationType {
+		return strconv.Quote(formatDuration(time.Duration(v.Int())))
+	}
+
+	kv := ": "
+	if e.format == FormatTOML {
+		kv = " = "
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = e.inline(v.Index(i))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case reflect.Map:
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+
+		items := make([]string, len(keys))
+		for i, k := range keys {
+			items[i] = strconv.Quote(k) + kv + e.inline(v.MapIndex(reflect.ValueOf(k)))
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	case reflect.Struct:
+		items := make([]string, v.NumField())
+		for i := range items {
+			items[i] = strconv.Quote(v.Type().Field(i).Name) + kv + e.inline(v.Field(i))
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	}
+
+	return fmt.Sprint(v.Interface())
+}
+
+// formatDuration форматирует d без нулевых младших единиц: 12h вместо 12h0m0s.
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/listener"
+	"github.com/dmitrykharchenko95/fibonacci/internal/logger"
+)
+
+// ValidationError перечисляет все неверные параметры конфигурации.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+// validator накапливает описания неверных параметров.
+type validator struct {
+	problems []string
+}
+
+// check добавляет описание problem неверного параметра name, если условие ok не выполнено.
+func (v *validator) check(ok bool, name, problem string) {
+	if !ok {
+		v.problems = append(v.problems, name+": "+problem)
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+
+	sort.Strings(v.problems)
+	return &ValidationError{Problems: v.problems}
+}
+
+// Validate проверяет значения параметров конфигурации. Ошибка перечисляет все неверные параметры, а не только первый.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.check(c.HTTP.Enabled || c.GRPC.Enabled, "HTTP.Enabled", "at least one of HTTP and gRPC servers should be enabled")
+	v.checkListen("HTTP", c.HTTP.Enabled, c.HTTP.Port, c.HTTP.Listen)
+	v.check(c.HTTP.Timeout > 0, "HTTP.Timeout", "should be positive")
+	v.check(c.HTTP.MaxPageSize > 0, "HTTP.MaxPageSize", "should be positive")
+	v.check(!c.HTTP.ClientCertRequired || c.HTTP.CAFile != "", "HTTP.ClientCertRequired", "requires HTTP.CAFile")
+	v.check((c.HTTP.CertFile == "") == (c.HTTP.KeyFile == ""), "HTTP.CertFile",
+		"should be set together with HTTP.KeyFile")
+	v.check(!c.HTTP.Gateway || (c.HTTP.Enabled && c.GRPC.Enabled), "HTTP.Gateway", "requires HTTP.Enabled and GRPC.Enabled")
+
+	v.checkListen("GRPC", c.GRPC.Enabled, c.GRPC.Port, c.GRPC.Listen)
+	v.check(c.GRPC.Timeout > 0, "GRPC.Timeout", "should be positive")
+	v.check(c.GRPC.MaxPageSize > 0, "GRPC.MaxPageSize", "should be positive")
+	v.check(!c.GRPC.ClientCertRequired || c.GRPC.CAFile != "", "GRPC.ClientCertRequired", "requires GRPC.CAFile")
+	v.check((c.GRPC.CertFile == "") == (c.GRPC.KeyFile == ""), "GRPC.CertFile",
+		"should be set together with GRPC.KeyFile")
+	v.check(!c.GRPC.Web || (c.HTTP.Enabled && c.GRPC.Enabled), "GRPC.Web", "requires HTTP.Enabled and GRPC.Enabled")
+
+	v.check(c.Redis.Expiration > 0, "Redis.Expiration", "should be positive")
+	v.check(c.Redis.MaxErrors >= 0, "Redis.MaxErrors", "should not be negative")
+	v.check(c.Redis.MaxErrors == 0 || c.Redis.Port != "", "Redis.Port", "should be set")
+
+	v.check(c.Scheduler.Workers >= 0, "Scheduler.Workers", "should not be negative")
+	v.check(c.Scheduler.QueueSize > 0, "Scheduler.QueueSize", "should be positive")
+
+	v.check(c.RateLimit.Rate >= 0, "RateLimit.Rate", "should not be negative")
+	v.check(c.RateLimit.Burst >= 0, "RateLimit.Burst", "should not be negative")
+	for name, l := range c.RateLimit.Endpoints {
+		v.check(l.Rate >= 0, "RateLimit.Endpoints."+name+".Rate", "should not be negative")
+		v.check(l.Burst >= 0, "RateLimit.Endpoints."+name+".Burst", "should not be negative")
+	}
+
+	v.check(c.Auth.HugeIndex >= 0, "Auth.HugeIndex", "should not be negative")
+	for i, k := range c.Auth.APIKeys {
+		v.check(k.Key != "", fmt.Sprintf("Auth.APIKeys[%d].Key", i), "should be set")
+		v.check(k.Name != "", fmt.Sprintf("Auth.APIKeys[%d].Name", i), "should be set")
+	}
+
+	v.checkQuotaLimits("Quota.Default", c.Quota.Default)
+	for name, l := range c.Quota.Clients {
+		v.checkQuotaLimits("Quota.Clients."+name, l)
+	}
+	v.check(!c.Quota.Enabled || c.Quota.UseRedis || c.Quota.StoreFile != "", "Quota.StoreFile",
+		"should be set when Quota.UseRedis is false")
+
+	v.check(!c.Metrics.Enabled || c.HTTP.Enabled, "Metrics.Enabled", "requires HTTP.Enabled")
+	v.check(!c.Metrics.Enabled || strings.HasPrefix(c.Metrics.Path, "/"), "Metrics.Path", "should start with \"/\"")
+
+	v.check(!c.Tracing.Enabled || c.Tracing.Endpoint != "", "Tracing.Endpoint", "should be set")
+	v.check(!c.Tracing.Enabled || c.Tracing.ServiceName != "", "Tracing.ServiceName", "should be set")
+	v.check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "Tracing.SampleRatio", "should be between 0 and 1")
+
+	_, err := logger.ParseLevel(c.Log.Level)
+	v.check(err == nil, "Log.Level", "should be one of debug, info, warn, error")
+	format := strings.ToLower(c.Log.Format)
+	v.check(format == logger.FormatJSON || format == logger.FormatLogfmt, "Log.Format", "should be json or logfmt")
+
+	v.check(strings.HasPrefix(c.Health.LivenessPath, "/"), "Health.LivenessPath", "should start with \"/\"")
+	v.check(strings.HasPrefix(c.Health.ReadinessPath, "/"), "Health.ReadinessPath", "should start with \"/\"")
+	v.check(c.Health.CheckTimeout > 0, "Health.CheckTimeout", "should be positive")
+	v.check(c.Health.WarmUpIndex >= 0, "Health.WarmUpIndex", "should not be negative")
+
+	v.check(!c.Admin.Enabled || c.Admin.Port != "", "Admin.Port", "should be set")
+
+	v.check(c.Shutdown.DrainDelay >= 0, "Shutdown.DrainDelay", "should not be negative")
+	v.check(c.Shutdown.GracePeriod > 0, "Shutdown.GracePeriod", "should be positive")
+
+	return v.err()
+}
+
+// checkListen проверяет адреса сервера section: заданный Port, если не заданы адреса Listen, и формат адресов Listen.
+func (v *validator) checkListen(section string, enabled bool, port string, listen []string) {
+	if !enabled {
+		return
+	}
+
+	v.check(len(listen) > 0 || port != "", section+".Port", "should be set")
+	for i, addr := range listen {
+		_, err := listener.Parse(addr)
+		v.check(err == nil, fmt.Sprintf("%s.Listen[%d]", section, i), "should be host:port, tcp://host:port, "+
+			"unix:///path or systemd:name")
+	}
+}
+
+func (v *validator) checkQuotaLimits(name string, l QuotaLimitsConfig) {
+	v.check(l.DailyComputeTime >= 0, name+".DailyComputeTime", "should not be negative")
+	v.check(l.MonthlyComputeTime >= 0, name+".MonthlyComputeTime", "should not be negative")
+	v.check(l.DailyBytes >= 0, name+".DailyBytes", "should not be negative")
+	v.check(l.MonthlyBytes >= 0, name+".MonthlyBytes", "should not be negative")
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
+// APIKey описывает статический API ключ клиента.
+type APIKey struct {
+	Key    string
+	Name   string
+	Scopes []string
+}
+
+// APIKeys проверяет API ключи по статическому списку.
+type APIKeys struct {
+	keys map[[sha256.Size]byte]APIKey
+}
+
+// NewAPIKeys создает новый объект типа APIKeys.
+func NewAPIKeys(keys []APIKey) *APIKeys {
+	a := &APIKeys{
+		keys: make(map[[sha256.Size]byte]APIKey, len(keys)),
+	}
+
+	for _, k := range keys {
+		a.keys[sha256.Sum256([]byte(k.Key))] = k
+	}
+
+	return a
+}
+
+// Authenticate реализует интерфейс Authenticator.
+func (a *APIKeys) Authenticate(_ context.Context, cred Credentials) (*Identity, error) {
+	if cred.APIKey == "" {
+		return nil, ErrNoCredentials
+	}
+
+	// сравнение хэшей не зависит по времени от совпадающего префикса ключа
+	k, ok := a.keys[sha256.Sum256([]byte(cred.APIKey))]
+	if !ok || subtle.ConstantTimeCompare([]byte(k.Key), []byte(cred.APIKey)) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+
+	return &Identity{
+		Subject: k.Name,
+		Method:  "apikey",
+		Scopes:  k.Scopes,
+	}, nil
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+const (
+	// ScopeHuge разрешает запросы чисел Фибоначчи с порядковыми номерами больше порога Guard.HugeIndex.
+	ScopeHuge = "fibonacci:huge"
+	// ScopeAdmin разрешает доступ к административным эндпоинтам.
+	ScopeAdmin = "admin"
+)
+
+var (
+	ErrNoCredentials      = errors.New("no credentials provided")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrForbidden          = errors.New("insufficient scope")
+)
+
+// Identity описывает аутентифицированного клиента.
+type Identity struct {
+	Subject string
+	Method  string
+	Scopes  []string
+}
+
+// HasScope сообщает, выдана ли клиенту область доступа scope.
+func (id *Identity) HasScope(scope string) bool {
+	if id == nil {
+		return false
+	}
+
+	for _, s := range id.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Credentials содержит учетные данные, переданные клиентом: API ключ и/или bearer токен.
+type Credentials struct {
+	APIKey string
+	Bearer string
+}
+
+// Authenticator проверяет учетные данные клиента. Если учетные данные нужного Authenticator типа не переданы,
+// Authenticate возвращает ошибку ErrNoCredentials.
+type Authenticator interface {
+	Authenticate(ctx context.Context, cred Credentials) (*Identity, error)
+}
+
+// Chain объединяет несколько Authenticator: учетные данные проверяются первым из них, для которого они переданы.
+type Chain []Authenticator
+
+// Authenticate реализует интерфейс Authenticator.
+func (c Chain) Authenticate(ctx context.Context, cred Credentials) (*Identity, error) {
+	for _, a := range c {
+		id, err := a.Authenticate(ctx, cred)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return id, err
+	}
+
+	return nil, ErrNoCredentials
+}
+
+// Guard проверяет права клиента на выполнение запроса.
+type Guard struct {
+	Authenticator  Authenticator
+	AllowAnonymous bool
+	HugeIndex      int
+}
+
+// Authenticate проверяет учетные данные клиента. Запросы без учетных данных допускаются только при AllowAnonymous, в
+// этом случае возвращается nil Identity.
+func (g *Guard) Authenticate(ctx context.Context, cred Credentials) (*Identity, error) {
+	id, err := g.Authenticator.Authenticate(ctx, cred)
+	if errors.Is(err, ErrNoCredentials) && g.AllowAnonymous {
+		return nil, nil
+	}
+
+	return id, err
+}
+
+// KeyIdentity возвращает Identity клиента с API ключом key или nil, если ключ не передан или неверен. Вызов KeyIdentity
+// у nil Guard всегда возвращает nil.
+func (g *Guard) KeyIdentity(ctx context.Context, key string) *Identity {
+	if g == nil || key == "" {
+		return nil
+	}
+
+	id, err := g.Authenticator.Authenticate(ctx, Credentials{APIKey: key})
+	if err != nil || id == nil || id.Subject == "" {
+		return nil
+	}
+	return id
+}
+
+// CheckRange проверяет, может ли клиент из ctx запросить числа Фибоначчи с порядковыми номерами от x до y. Для номеров,
+// превышающих по модулю HugeIndex, требуется область доступа ScopeHuge. Вызов CheckRange у nil Guard или при нулевом
+// HugeIndex не накладывает ограничений.
+func (g *Guard) CheckRange(ctx context.Context, x, y int) error {
+	if g == nil || g.HugeIndex <= 0 {
+		return nil
+	}
+
+	// HugeIndex положителен, поэтому -HugeIndex не переполняется, в отличие от модуля math.MinInt.
+	if huge := g.HugeIndex; -huge <= min(x, y) && max(x, y) <= huge {
+		return nil
+	}
+
+	if FromContext(ctx).HasScope(ScopeHuge) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: indices above %v require scope %q", ErrForbidden, g.HugeIndex, ScopeHuge)
+}
+
+// RequireScope проверяет, выдана ли клиенту из ctx область доступа scope.
+func RequireScope(ctx context.Context, scope string) error {
+	if FromContext(ctx).HasScope(scope) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: scope %q required", ErrForbidden, scope)
+}
+
+type ctxKey struct{}
+
+// NewContext возвращает копию ctx, содержащую id.
+func NewContext(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext возвращает Identity клиента из ctx или nil для анонимного клиента.
+func FromContext(ctx context.Context) *Identity {
+	id, _ := ctx.Value(ctxKey{}).(*Identity)
+	return id
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeys(t *testing.T) {
+	a := NewAPIKeys([]APIKey{{Key: "secret", Name: "analytics", Scopes: []string{ScopeHuge}}})
+
+	_, err := a.Authenticate(context.Background(), Credentials{})
+	require.ErrorIs(t, err, ErrNoCredentials)
+
+	_, err = a.Authenticate(context.Background(), Credentials{APIKey: "wrong"})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	id, err := a.Authenticate(context.Background(), Credentials{APIKey: "secret"})
+	require.NoError(t, err)
+	require.Equal(t, "analytics", id.Subject)
+	require.True(t, id.HasScope(ScopeHuge))
+	require.False(t, id.HasScope(ScopeAdmin))
+}
+
+func TestJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(t.TempDir(), "jwt.pub")
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}), 0o600)
+	require.NoError(t, err)
+
+	j, err := NewJWT("hmac-secret", keyFile, "issuer", "fibonacci")
+	require.NoError(t, err)
+
+	newClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub":   "service",
+			"iss":   "issuer",
+			"aud":   "fibonacci",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"scope": "fibonacci:huge admin",
+		}
+	}
+
+	t.Run("hmac", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims()).SignedString([]byte("hmac-secret"))
+		require.NoError(t, err)
+
+		id, err := j.Authenticate(context.Background(), Credentials{Bearer: token})
+		require.NoError(t, err)
+		require.Equal(t, "service", id.Subject)
+		require.True(t, id.HasScope(ScopeHuge))
+		require.True(t, id.HasScope(ScopeAdmin))
+	})
+
+	t.Run("rsa", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, newClaims()).SignedString(key)
+		require.NoError(t, err)
+
+		id, err := j.Authenticate(context.Background(), Credentials{Bearer: token})
+		require.NoError(t, err)
+		require.Equal(t, "service", id.Subject)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims()).SignedString([]byte("other"))
+		require.NoError(t, err)
+
+		_, err = j.Authenticate(context.Background(), Credentials{Bearer: token})
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		c := newClaims()
+		c["exp"] = time.Now().Add(-time.Minute).Unix()
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString([]byte("hmac-secret"))
+		require.NoError(t, err)
+
+		_, err = j.Authenticate(context.Background(), Credentials{Bearer: token})
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("no expiration", func(t *testing.T) {
+		c := newClaims()
+		delete(c, "exp")
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString([]byte("hmac-secret"))
+		require.NoError(t, err)
+
+		_, err = j.Authenticate(context.Background(), Credentials{Bearer: token})
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		c := newClaims()
+		c["aud"] = "other"
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString([]byte("hmac-secret"))
+		require.NoError(t, err)
+
+		_, err = j.Authenticate(context.Background(), Credentials{Bearer: token})
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+}
+
+func TestGuard(t *testing.T) {
+	g := &Guard{
+		Authenticator:  Chain{NewAPIKeys([]APIKey{{Key: "huge", Name: "huge", Scopes: []string{ScopeHuge}}})},
+		AllowAnonymous: true,
+		HugeIndex:      1000,
+	}
+
+	id, err := g.Authenticate(context.Background(), Credentials{})
+	require.NoError(t, err)
+	require.Nil(t, id)
+
+	anon := NewContext(context.Background(), id)
+	require.NoError(t, g.CheckRange(anon, -1000, 1000))
+	require.ErrorIs(t, g.CheckRange(anon, 0, 1001), ErrForbidden)
+	require.ErrorIs(t, g.CheckRange(anon, -1001, 0), ErrForbidden)
+	require.ErrorIs(t, g.CheckRange(anon, math.MinInt, math.MinInt), ErrForbidden)
+	require.ErrorIs(t, g.CheckRange(anon, math.MinInt, 0), ErrForbidden)
+
+	id, err = g.Authenticate(context.Background(), Credentials{APIKey: "huge"})
+	require.NoError(t, err)
+	require.NoError(t, g.CheckRange(NewContext(context.Background(), id), 0, 1001))
+
+	require.Equal(t, "huge", g.KeyIdentity(context.Background(), "huge").Subject)
+	require.Nil(t, g.KeyIdentity(context.Background(), "wrong"))
+	require.Nil(t, g.KeyIdentity(context.Background(), ""))
+	require.Nil(t, (*Guard)(nil).KeyIdentity(context.Background(), "huge"))
+
+	g.AllowAnonymous = false
+	_, err = g.Authenticate(context.Background(), Credentials{})
+	require.ErrorIs(t, err, ErrNoCredentials)
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// JWT проверяет bearer токены формата JWT, подписанные алгоритмами HMAC (HS256/384/512) или RSA (RS256/384/512).
+// Области доступа клиента считываются из claim "scope" (строка через пробел) или "scp" (массив строк).
+type JWT struct {
+	hmacSecret []byte
+	rsaKey     *rsa.PublicKey
+	issuer     string
+	audience   string
+}
+
+// NewJWT создает новый объект типа JWT. Для проверки подписи используется hmacSecret и/или публичный RSA ключ в формате
+// PEM из файла rsaKeyFile. Непустые issuer и audience сверяются с claims "iss" и "aud" токена.
+func NewJWT(hmacSecret, rsaKeyFile, issuer, audience string) (*JWT, error) {
+	j := &JWT{
+		issuer:   issuer,
+		audience: audience,
+	}
+
+	if hmacSecret != "" {
+		j.hmacSecret = []byte(hmacSecret)
+	}
+
+	if rsaKeyFile != "" {
+		pem, err := os.ReadFile(rsaKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read rsa public key: %w", err)
+		}
+
+		j.rsaKey, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("parse rsa public key: %w", err)
+		}
+	}
+
+	if j.hmacSecret == nil && j.rsaKey == nil {
+		return nil, fmt.Errorf("jwt: neither hmac secret nor rsa public key is set")
+	}
+
+	return j, nil
+}
+
+type claims struct {
+	jwt.RegisteredClaims
+	Scope string   `json:"scope,omitempty"`
+	Scp   []string `json:"scp,omitempty"`
+}
+
+// Authenticate реализует интерфейс Authenticator.
+func (j *JWT) Authenticate(_ context.Context, cred Credentials) (*Identity, error) {
+	if cred.Bearer == "" {
+		return nil, ErrNoCredentials
+	}
+
+	c := &claims{}
+	_, err := jwt.ParseWithClaims(cred.Bearer, c, j.key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	// Библиотека проверяет exp, только если он задан; токены без срока действия не принимаются.
+	if c.ExpiresAt == nil {
+		return nil, fmt.Errorf("%w: missing expiration time", ErrInvalidCredentials)
+	}
+
+	if j.issuer != "" && !c.VerifyIssuer(j.issuer, true) {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidCredentials)
+	}
+
+	if j.audience != "" && !c.VerifyAudience(j.audience, true) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidCredentials)
+	}
+
+	scopes := c.Scp
+	if c.Scope != "" {
+		scopes = append(scopes, strings.Fields(c.Scope)...)
+	}
+
+	return &Identity{
+		Subject: c.Subject,
+		Method:  "jwt",
+		Scopes:  scopes,
+	}, nil
+}
+
+// key выбирает ключ проверки подписи по алгоритму токена.
+func (j *JWT) key(t *jwt.Token) (interface{}, error) {
+	switch t.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if j.hmacSecret != nil {
+			return j.hmacSecret, nil
+		}
+	case *jwt.SigningMethodRSA:
+		if j.rsaKey != nil {
+			return j.rsaKey, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+}
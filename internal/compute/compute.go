@@ -0,0 +1,68 @@
+// Package compute выполняет вычисления чисел Фибоначчи по запросам клиентов с общими для всех серверов проверками:
+// правами клиента на порядковые номера, ограничением количества одновременных вычислений и учетом времени вычислений
+// в квоте клиента.
+package compute
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/auth"
+	"github.com/dmitrykharchenko95/fibonacci/internal/quota"
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
+)
+
+// Runner выполняет вычисления через планировщик sch с проверкой прав клиентов guard (nil guard не накладывает
+// ограничений).
+type Runner struct {
+	sch   *scheduler.Scheduler
+	guard *auth.Guard
+}
+
+// New создает новый объект типа Runner.
+func New(sch *scheduler.Scheduler, guard *auth.Guard) *Runner {
+	return &Runner{sch: sch, guard: guard}
+}
+
+// Check проверяет права клиента client из ctx на порядковые номера от lo до hi.
+func (r *Runner) Check(ctx context.Context, client string, lo, hi int) error {
+	if err := r.guard.CheckRange(ctx, lo, hi); err != nil {
+		slog.WarnContext(ctx, "access denied", "client", client, "error", err)
+		return err
+	}
+	return nil
+}
+
+// Run проверяет права клиента client на порядковые номера от lo до hi, дожидается свободного места в планировщике для
+// вычисления стоимостью cost и выполняет fn, учитывая время ее выполнения в квоте клиента. Ожидание в очереди и
+// вычисление вместе длятся не дольше timeout: через аргумент fn получает время, оставшееся после ожидания. Ошибки
+// проверки прав и планировщика возвращаются как есть, без вызова fn; иначе возвращается ошибка fn.
+func (r *Runner) Run(ctx context.Context, client string, lo, hi int, cost int64, timeout time.Duration,
+	fn func(timeout time.Duration) error) error {
+	if err := r.Check(ctx, client, lo, hi); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+	wctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	release, err := r.sch.Acquire(wctx, cost)
+	if err != nil {
+		slog.WarnContext(ctx, "request rejected", "client", client, "error", err)
+		return err
+	}
+	defer release()
+
+	left := time.Until(deadline)
+	if left <= 0 {
+		slog.WarnContext(ctx, "request rejected", "client", client, "error", scheduler.ErrQueueTimeout)
+		return scheduler.ErrQueueTimeout
+	}
+
+	start := time.Now()
+	err = fn(left)
+	quota.RecordCompute(ctx, time.Since(start))
+	return err
+}
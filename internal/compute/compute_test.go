@@ -0,0 +1,70 @@
+package compute
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/auth"
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ok", func(t *testing.T) {
+		r := New(scheduler.New(1, 1), nil)
+
+		var got time.Duration
+		err := r.Run(ctx, "client", 0, 10, 1, time.Second, func(timeout time.Duration) error {
+			got = timeout
+			return nil
+		})
+		require.NoError(t, err)
+		require.Positive(t, got)
+		require.LessOrEqual(t, got, time.Second)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		r := New(scheduler.New(1, 1), &auth.Guard{AllowAnonymous: true, HugeIndex: 100})
+
+		called := false
+		err := r.Run(ctx, "client", 0, 101, 1, time.Second, func(time.Duration) error {
+			called = true
+			return nil
+		})
+		require.ErrorIs(t, err, auth.ErrForbidden)
+		require.False(t, called)
+	})
+
+	t.Run("queue timeout", func(t *testing.T) {
+		sch := scheduler.New(1, 1)
+		release, err := sch.Acquire(ctx, 1)
+		require.NoError(t, err)
+		defer release()
+
+		called := false
+		err = New(sch, nil).Run(ctx, "client", 0, 1, 1, 10*time.Millisecond, func(time.Duration) error {
+			called = true
+			return nil
+		})
+		require.ErrorIs(t, err, scheduler.ErrQueueTimeout)
+		require.False(t, called)
+	})
+
+	t.Run("queue wait counts towards timeout", func(t *testing.T) {
+		sch := scheduler.New(1, 1)
+		release, err := sch.Acquire(ctx, 1)
+		require.NoError(t, err)
+		time.AfterFunc(50*time.Millisecond, release)
+
+		var got time.Duration
+		err = New(sch, nil).Run(ctx, "client", 0, 1, 1, time.Second, func(timeout time.Duration) error {
+			got = timeout
+			return nil
+		})
+		require.NoError(t, err)
+		require.LessOrEqual(t, got, time.Second-50*time.Millisecond)
+	})
+}
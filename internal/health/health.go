@@ -0,0 +1,100 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrWarmingUp = errors.New("warming up")
+	ErrDraining  = errors.New("draining")
+)
+
+// Check - проверка зависимости сервиса. Возвращает ошибку, если зависимость недоступна.
+type Check func(ctx context.Context) error
+
+type check struct {
+	name     string
+	critical bool
+	fn       Check
+}
+
+// Checker определяет готовность сервиса к обработке запросов по результатам проверок зависимостей, завершению прогрева и
+// началу остановки.
+type Checker struct {
+	checks  []check
+	timeout time.Duration
+	warm    atomic.Bool
+	drain   atomic.Bool
+}
+
+// New создает Checker. Каждая проверка ограничивается временем timeout. Сервис считается не готовым, пока не вызван
+// WarmedUp.
+func New(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add добавляет проверку fn с именем name. Неудачная критическая (critical) проверка делает сервис не готовым,
+// некритическая - только отражается в отчете. Должен вызываться до начала обработки запросов.
+func (c *Checker) Add(name string, critical bool, fn Check) {
+	c.checks = append(c.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// WarmedUp отмечает завершение прогрева сервиса.
+func (c *Checker) WarmedUp() {
+	c.warm.Store(true)
+}
+
+// Drain отмечает начало остановки сервиса. После вызова сервис считается не готовым.
+func (c *Checker) Drain() {
+	c.drain.Store(true)
+}
+
+// Draining сообщает, начата ли остановка сервиса.
+func (c *Checker) Draining() bool {
+	return c.drain.Load()
+}
+
+// Report - результат проверки готовности. Checks содержит ok или текст ошибки для каждой проверки.
+type Report struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]string `json:"checks"`
+}
+
+// Ready выполняет все проверки и возвращает отчет о готовности сервиса.
+func (c *Checker) Ready(ctx context.Context) Report {
+	r := Report{Ready: true, Checks: make(map[string]string, len(c.checks)+2)}
+
+	set := func(name string, critical bool, err error) {
+		if err == nil {
+			r.Checks[name] = "ok"
+			return
+		}
+		r.Checks[name] = err.Error()
+		if critical {
+			r.Ready = false
+		}
+	}
+
+	if c.drain.Load() {
+		set("shutdown", true, ErrDraining)
+	} else {
+		set("shutdown", true, nil)
+	}
+
+	if c.warm.Load() {
+		set("warmup", true, nil)
+	} else {
+		set("warmup", true, ErrWarmingUp)
+	}
+
+	for _, ch := range c.checks {
+		cctx, cancel := context.WithTimeout(ctx, c.timeout)
+		set(ch.name, ch.critical, ch.fn(cctx))
+		cancel()
+	}
+
+	return r
+}
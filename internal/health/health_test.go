@@ -0,0 +1,56 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker(t *testing.T) {
+	c := New(time.Second)
+
+	var redisErr error
+	c.Add("redis", false, func(ctx context.Context) error { return redisErr })
+
+	var diskErr error
+	c.Add("disk", true, func(ctx context.Context) error { return diskErr })
+
+	r := c.Ready(context.Background())
+	require.False(t, r.Ready)
+	require.Equal(t, ErrWarmingUp.Error(), r.Checks["warmup"])
+
+	c.WarmedUp()
+	r = c.Ready(context.Background())
+	require.True(t, r.Ready)
+	require.Equal(t, map[string]string{"shutdown": "ok", "warmup": "ok", "redis": "ok", "disk": "ok"}, r.Checks)
+
+	redisErr = errors.New("connection refused")
+	r = c.Ready(context.Background())
+	require.True(t, r.Ready)
+	require.Equal(t, "connection refused", r.Checks["redis"])
+
+	diskErr = errors.New("no space")
+	require.False(t, c.Ready(context.Background()).Ready)
+
+	diskErr = nil
+	c.Drain()
+	r = c.Ready(context.Background())
+	require.False(t, r.Ready)
+	require.Equal(t, ErrDraining.Error(), r.Checks["shutdown"])
+}
+
+func TestCheckerTimeout(t *testing.T) {
+	c := New(10 * time.Millisecond)
+	c.WarmedUp()
+	c.Add("slow", true, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	r := c.Ready(context.Background())
+	require.False(t, r.Ready)
+	require.Equal(t, context.DeadlineExceeded.Error(), r.Checks["slow"])
+}
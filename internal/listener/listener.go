@@ -0,0 +1,183 @@
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Сети адресов прослушивания.
+const (
+	NetworkTCP     = "tcp"
+	NetworkUnix    = "unix"
+	NetworkSystemd = "systemd"
+)
+
+// listenFDsStart - номер первого файлового дескриптора, передаваемого systemd при активации по сокету.
+const listenFDsStart = 3
+
+// Address - разобранный адрес прослушивания.
+type Address struct {
+	Network string
+	Address string
+}
+
+func (a Address) String() string {
+	if a.Network == NetworkTCP {
+		return a.Address
+	}
+	return a.Network + ":" + a.Address
+}
+
+// Parse разбирает адрес прослушивания addr. Поддерживаются форматы:
+//   - host:port или tcp://host:port - TCP сокет;
+//   - unix:///path/to.sock или unix:path/to.sock - Unix сокет;
+//   - systemd:name - сокет, переданный systemd при активации по сокету, с именем name (FileDescriptorName) или
+//     порядковым номером name.
+func Parse(addr string) (Address, error) {
+	network, rest, ok := strings.Cut(addr, ":")
+	switch {
+	case ok && network == NetworkUnix:
+		rest = strings.TrimPrefix(rest, "//")
+		if rest == "" {
+			return Address{}, fmt.Errorf("listen address %q: empty socket path", addr)
+		}
+		return Address{Network: NetworkUnix, Address: rest}, nil
+	case ok && network == NetworkSystemd:
+		rest = strings.TrimPrefix(rest, "//")
+		if rest == "" {
+			return Address{}, fmt.Errorf("listen address %q: empty socket name", addr)
+		}
+		return Address{Network: NetworkSystemd, Address: rest}, nil
+	case ok && network == NetworkTCP:
+		addr = strings.TrimPrefix(rest, "//")
+	}
+
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return Address{}, fmt.Errorf("listen address %q: %w", addr, err)
+	}
+	return Address{Network: NetworkTCP, Address: addr}, nil
+}
+
+// Listen создает слушатель по адресу addr в одном из форматов Parse. Оставшийся от предыдущего запуска файл Unix сокета
+// удаляется.
+func Listen(addr string) (net.Listener, error) {
+	a, err := Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	switch a.Network {
+	case NetworkUnix:
+		if fi, err := os.Lstat(a.Address); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			if err := os.Remove(a.Address); err != nil {
+				return nil, fmt.Errorf("remove stale socket: %w", err)
+			}
+		}
+		return net.Listen(NetworkUnix, a.Address)
+	case NetworkSystemd:
+		return systemdListener(a.Address)
+	}
+
+	return net.Listen(NetworkTCP, a.Address)
+}
+
+// ListenAll создает слушатели по всем адресам addrs. При ошибке уже созданные слушатели закрываются.
+func ListenAll(addrs []string) ([]net.Listener, error) {
+	lsns := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		l, err := Listen(addr)
+		if err != nil {
+			for _, l := range lsns {
+				l.Close()
+			}
+			return nil, err
+		}
+		lsns = append(lsns, l)
+	}
+	return lsns, nil
+}
+
+var (
+	systemdOnce  sync.Once
+	systemdFiles []*os.File
+	systemdErr   error
+)
+
+// systemdListener возвращает слушатель по сокету, переданному systemd, с именем или порядковым номером name. Каждый
+// сокет может быть использован только один раз.
+func systemdListener(name string) (net.Listener, error) {
+	systemdOnce.Do(func() {
+		systemdFiles, systemdErr = activationFiles()
+	})
+	if systemdErr != nil {
+		return nil, systemdErr
+	}
+
+	idx := -1
+	for i, f := range systemdFiles {
+		if f != nil && f.Name() == name {
+			idx = i
+			break
+		}
+	}
+	if i, err := strconv.Atoi(name); idx < 0 && err == nil && i >= 0 && i < len(systemdFiles) {
+		idx = i
+	}
+	if idx < 0 || systemdFiles[idx] == nil {
+		return nil, fmt.Errorf("systemd socket %q not passed or already used", name)
+	}
+
+	f := systemdFiles[idx]
+	systemdFiles[idx] = nil
+	defer f.Close()
+
+	return net.FileListener(f)
+}
+
+// activationFiles возвращает файлы сокетов, переданных systemd через переменные окружения LISTEN_PID, LISTEN_FDS и
+// LISTEN_FDNAMES.
+func activationFiles() ([]*os.File, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, errors.New("no sockets passed by systemd")
+	}
+
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, errors.New("no sockets passed by systemd")
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	files := make([]*os.File, n)
+	for i := range files {
+		name := strconv.Itoa(i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		files[i] = os.NewFile(uintptr(listenFDsStart+i), name)
+	}
+
+	return files, nil
+}
+
+// ServeAll вызывает serve для каждого из слушателей lsns параллельно и ждет их завершения. При первой ошибке ServeAll
+// сразу возвращает ее, не дожидаясь остальных.
+func ServeAll(lsns []net.Listener, serve func(net.Listener) error) error {
+	errCh := make(chan error, len(lsns))
+	for _, l := range lsns {
+		go func(l net.Listener) {
+			errCh <- serve(l)
+		}(l)
+	}
+
+	for range lsns {
+		if err := <-errCh; err != nil {
+			return err
+		}
+	}
+	return nil
+}
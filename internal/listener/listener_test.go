@@ -0,0 +1,91 @@
+package listener
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		addr    string
+		want    Address
+		wantErr bool
+	}{
+		{addr: "0.0.0.0:8080", want: Address{Network: NetworkTCP, Address: "0.0.0.0:8080"}},
+		{addr: "tcp://[::1]:8080", want: Address{Network: NetworkTCP, Address: "[::1]:8080"}},
+		{addr: ":8080", want: Address{Network: NetworkTCP, Address: ":8080"}},
+		{addr: "unix:///run/fibonacci.sock", want: Address{Network: NetworkUnix, Address: "/run/fibonacci.sock"}},
+		{addr: "unix:fibonacci.sock", want: Address{Network: NetworkUnix, Address: "fibonacci.sock"}},
+		{addr: "systemd:http", want: Address{Network: NetworkSystemd, Address: "http"}},
+		{addr: "localhost", wantErr: true},
+		{addr: "unix://", wantErr: true},
+		{addr: "systemd:", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			got, err := Parse(tt.addr)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestListen(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "fibonacci.sock")
+
+	t.Run("unix socket replaces stale file", func(t *testing.T) {
+		stale, err := net.Listen("unix", sock)
+		require.NoError(t, err)
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		require.NoError(t, stale.Close())
+
+		l, err := Listen("unix://" + sock)
+		require.NoError(t, err)
+		defer l.Close()
+
+		conn, err := net.Dial("unix", sock)
+		require.NoError(t, err)
+		conn.Close()
+	})
+
+	t.Run("regular file is not removed", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "data")
+		require.NoError(t, os.WriteFile(file, nil, 0o600))
+
+		_, err := Listen("unix://" + file)
+		require.Error(t, err)
+		require.FileExists(t, file)
+	})
+
+	t.Run("listen all closes on error", func(t *testing.T) {
+		_, err := ListenAll([]string{"127.0.0.1:0", "bad"})
+		require.Error(t, err)
+	})
+
+	t.Run("systemd without sockets", func(t *testing.T) {
+		_, err := Listen("systemd:http")
+		require.EqualError(t, err, "no sockets passed by systemd")
+	})
+}
+
+func TestServeAll(t *testing.T) {
+	lsns, err := ListenAll([]string{"127.0.0.1:0", "127.0.0.1:0"})
+	require.NoError(t, err)
+
+	served := make(chan string, len(lsns))
+	err = ServeAll(lsns, func(l net.Listener) error {
+		served <- l.Addr().String()
+		return l.Close()
+	})
+	require.NoError(t, err)
+	require.Len(t, served, 2)
+}
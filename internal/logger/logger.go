@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const (
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+)
+
+// level - текущий уровень логирования, который можно изменить во время работы сервиса через SetLevel.
+var level = new(slog.LevelVar)
+
+// New создает логгер, выводящий записи в w в формате format (json или logfmt) начиная с уровня lvl (debug, info,
+// warn, error). В каждую запись добавляется идентификатор запроса из контекста.
+func New(w io.Writer, lvl, format string) (*slog.Logger, error) {
+	if err := SetLevel(lvl); err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case FormatJSON, "":
+		h = slog.NewJSONHandler(w, opts)
+	case FormatLogfmt:
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+
+	return slog.New(&contextHandler{Handler: h}), nil
+}
+
+// ParseLevel разбирает уровень логирования lvl (debug, info, warn, error). Пустая строка соответствует уровню info.
+func ParseLevel(lvl string) (slog.Level, error) {
+	var l slog.Level
+	if lvl != "" {
+		if err := l.UnmarshalText([]byte(lvl)); err != nil {
+			return 0, fmt.Errorf("unknown log level %q", lvl)
+		}
+	}
+	return l, nil
+}
+
+// SetLevel изменяет уровень логирования.
+func SetLevel(lvl string) error {
+	l, err := ParseLevel(lvl)
+	if err != nil {
+		return err
+	}
+
+	level.Set(l)
+	return nil
+}
+
+// Level возвращает текущий уровень логирования.
+func Level() string {
+	return strings.ToLower(level.Level().String())
+}
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	debugKey
+)
+
+// WithRequestID возвращает копию ctx, содержащую идентификатор запроса id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID возвращает идентификатор запроса из ctx.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// NewRequestID генерирует новый случайный идентификатор запроса.
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// WithDebug возвращает копию ctx, для которого записи уровня debug выводятся независимо от текущего уровня
+// логирования.
+func WithDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugKey, true)
+}
+
+// contextHandler фильтрует записи по текущему уровню логирования с учетом отладки отдельных запросов и добавляет в них
+// идентификатор запроса.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	if l >= level.Level() {
+		return true
+	}
+
+	debug, _ := ctx.Value(debugKey).(bool)
+	return debug
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
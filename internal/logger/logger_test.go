@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l, err := New(buf, "info", FormatJSON)
+	require.NoError(t, err)
+
+	ctx := WithRequestID(context.Background(), "req-1")
+
+	t.Run("request id", func(t *testing.T) {
+		buf.Reset()
+		l.InfoContext(ctx, "sent numbers", "count", 11)
+
+		rec := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &rec))
+		require.Equal(t, "INFO", rec["level"])
+		require.Equal(t, "sent numbers", rec["msg"])
+		require.Equal(t, "req-1", rec["request_id"])
+		require.Equal(t, float64(11), rec["count"])
+	})
+
+	t.Run("level", func(t *testing.T) {
+		buf.Reset()
+		l.DebugContext(ctx, "hidden")
+		require.Empty(t, buf.String())
+
+		require.NoError(t, SetLevel("debug"))
+		defer SetLevel("info")
+		require.Equal(t, "debug", Level())
+
+		l.DebugContext(ctx, "visible")
+		require.Contains(t, buf.String(), "visible")
+	})
+
+	t.Run("request debug", func(t *testing.T) {
+		buf.Reset()
+		l.DebugContext(WithDebug(ctx), "visible")
+		require.Contains(t, buf.String(), "visible")
+	})
+
+	t.Run("logfmt", func(t *testing.T) {
+		buf.Reset()
+		l, err := New(buf, "info", FormatLogfmt)
+		require.NoError(t, err)
+
+		l.InfoContext(ctx, "started")
+		require.True(t, strings.Contains(buf.String(), "msg=started"))
+		require.True(t, strings.Contains(buf.String(), "request_id=req-1"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := New(buf, "verbose", FormatJSON)
+		require.Error(t, err)
+		_, err = New(buf, "info", "xml")
+		require.Error(t, err)
+	})
+}
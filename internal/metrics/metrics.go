@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "fibonacci"
+
+// Registry содержит все метрики сервиса.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// Requests - количество обработанных запросов по транспорту (http/grpc), эндпоинту и статусу ответа.
+	Requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_total",
+		Help:      "Number of handled requests by transport, endpoint and status.",
+	}, []string{"transport", "endpoint", "status"})
+
+	// RequestDuration - время обработки запросов по транспорту и статусу ответа.
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "request_duration_seconds",
+		Help:      "Request handling latency by transport and status.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{"transport", "status"})
+
+	// ResponseSize - размер ответов по транспорту.
+	ResponseSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "response_size_bytes",
+		Help:      "Response size by transport.",
+		Buckets:   prometheus.ExponentialBuckets(64, 4, 10),
+	}, []string{"transport"})
+
+	// ComputeDuration - время вычисления диапазона чисел Фибоначчи по порядку наибольшего порядкового номера.
+	ComputeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "compute_duration_seconds",
+		Help:      "Fibonacci range computation time by index size bucket.",
+		Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 12),
+	}, []string{"index_size"})
+
+	// ComputeInFlight - количество выполняемых в данный момент вычислений.
+	ComputeInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "compute_in_flight",
+		Help:      "Number of computations in progress.",
+	})
+
+	// Timeouts - количество вычислений, прерванных по таймауту.
+	Timeouts = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "compute_timeouts_total",
+		Help:      "Number of computations interrupted by timeout.",
+	})
+
+	// RedisHits и RedisMisses - количество найденных и не найденных в кэше Redis значений.
+	RedisHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redis_hits_total",
+		Help:      "Number of values found in Redis cache.",
+	})
+	RedisMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redis_misses_total",
+		Help:      "Number of values not found in Redis cache.",
+	})
+
+	// RedisErrors - количество ошибок Redis по операции (get/set).
+	RedisErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redis_errors_total",
+		Help:      "Number of Redis errors by operation.",
+	}, []string{"op"})
+
+	// RedisEnabledFunc сообщает, используется ли Redis для кэширования. Устанавливается пакетом service.
+	RedisEnabledFunc = func() bool { return false }
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		Requests,
+		RequestDuration,
+		ResponseSize,
+		ComputeDuration,
+		ComputeInFlight,
+		Timeouts,
+		RedisHits,
+		RedisMisses,
+		RedisErrors,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "redis_enabled",
+			Help:      "Whether Redis cache is in use (1) or disabled (0).",
+		}, func() float64 {
+			if RedisEnabledFunc() {
+				return 1
+			}
+			return 0
+		}),
+	)
+}
+
+// Handler возвращает http.Handler, отдающий метрики в текстовом формате Prometheus.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// IndexSize возвращает метку порядка наибольшего по модулю порядкового номера из x и y: "10", "100", ... "1000000"
+// или "+Inf" для больших номеров.
+func IndexSize(x, y int) string {
+	n := abs(x)
+	if m := abs(y); m > n {
+		n = m
+	}
+
+	for limit := 10; limit <= 1000000; limit *= 10 {
+		if n <= limit {
+			return strconv.Itoa(limit)
+		}
+	}
+
+	return "+Inf"
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	Requests.WithLabelValues("http", "/", "200").Inc()
+	ComputeDuration.WithLabelValues(IndexSize(0, 10)).Observe(0.01)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+
+	require.Contains(t, string(body), `fibonacci_requests_total{endpoint="/",status="200",transport="http"} 1`)
+	require.Contains(t, string(body), `fibonacci_compute_duration_seconds_count{index_size="10"} 1`)
+	require.Contains(t, string(body), "fibonacci_redis_enabled 0")
+	require.Contains(t, string(body), "go_goroutines")
+}
+
+func TestIndexSize(t *testing.T) {
+	require.Equal(t, "10", IndexSize(0, 10))
+	require.Equal(t, "100", IndexSize(-11, 5))
+	require.Equal(t, "1000000", IndexSize(0, 1000000))
+	require.Equal(t, "+Inf", IndexSize(0, 1000001))
+}
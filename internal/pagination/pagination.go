@@ -0,0 +1,109 @@
+// Package pagination разбивает широкие диапазоны порядковых номеров на страницы. Положение следующей страницы
+// передается клиенту непрозрачным токеном, поэтому каждая страница вычисляется (или берется из кэша) отдельным
+// запросом, и клиент может пройти диапазон любой ширины.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+)
+
+// DefaultMaxSize - наибольший размер страницы по умолчанию.
+const DefaultMaxSize = 10000
+
+// tokenVersion - версия формата токена, первый байт декодированного токена.
+const tokenVersion = 1
+
+var (
+	ErrInvalidToken  = errors.New("invalid page token")
+	ErrTokenMismatch = errors.New("page token does not match the requested range")
+)
+
+// Page - страница диапазона порядковых номеров: номера от From до To включительно.
+type Page struct {
+	From, To    int
+	first, last int
+}
+
+// Size возвращает размер страницы для запрошенного клиентом size: нулевой size или size больше max заменяется на max.
+func Size(size, max int) int {
+	if size <= 0 || size > max {
+		return max
+	}
+	return size
+}
+
+// Paginate возвращает страницу диапазона from..to не больше size номеров (size > 0). Страница начинается с номера,
+// закодированного в token, или с from при пустом token. Токен должен быть получен для того же диапазона from..to,
+// иначе возвращается ErrTokenMismatch; поврежденный токен дает ErrInvalidToken.
+func Paginate(from, to, size int, token string) (Page, error) {
+	next := from
+	if token != "" {
+		f, t, n, err := decode(token)
+		if err != nil {
+			return Page{}, err
+		}
+		if f != from || t != to {
+			return Page{}, ErrTokenMismatch
+		}
+		next = n
+	}
+
+	last := to
+	if uint64(to)-uint64(next) >= uint64(size) {
+		last = next + size - 1
+	}
+
+	return Page{From: next, To: last, first: from, last: to}, nil
+}
+
+// Len возвращает количество номеров страницы.
+func (p Page) Len() int {
+	return p.To - p.From + 1
+}
+
+// Next возвращает токен страницы, следующей за первыми done номерами p: при done меньше p.Len() следующая страница
+// начинается с первого невычисленного номера. Для последней страницы диапазона возвращается пустая строка.
+func (p Page) Next(done int) string {
+	switch {
+	case done < p.Len():
+		return encode(p.first, p.last, p.From+done)
+	case p.To == p.last:
+		return ""
+	}
+	return encode(p.first, p.last, p.To+1)
+}
+
+// encode кодирует в токен диапазон from..to и первый номер следующей страницы next.
+func encode(from, to, next int) string {
+	buf := []byte{tokenVersion}
+	buf = binary.AppendVarint(buf, int64(from))
+	buf = binary.AppendVarint(buf, int64(to))
+	buf = binary.AppendVarint(buf, int64(next))
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// decode возвращает диапазон и первый номер следующей страницы из токена token.
+func decode(token string) (int, int, int, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(buf) == 0 || buf[0] != tokenVersion {
+		return 0, 0, 0, ErrInvalidToken
+	}
+
+	var vals [3]int
+	buf = buf[1:]
+	for i := range vals {
+		v, n := binary.Varint(buf)
+		if n <= 0 {
+			return 0, 0, 0, ErrInvalidToken
+		}
+		vals[i], buf = int(v), buf[n:]
+	}
+
+	from, to, next := vals[0], vals[1], vals[2]
+	if len(buf) != 0 || from > to || next < from || next > to {
+		return 0, 0, 0, ErrInvalidToken
+	}
+	return from, to, next, nil
+}
@@ -0,0 +1,69 @@
+package pagination
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSize(t *testing.T) {
+	require.Equal(t, 100, Size(0, 100))
+	require.Equal(t, 100, Size(-1, 100))
+	require.Equal(t, 100, Size(1000, 100))
+	require.Equal(t, 10, Size(10, 100))
+}
+
+func TestPaginate(t *testing.T) {
+	var (
+		pages []Page
+		token string
+	)
+	for {
+		p, err := Paginate(-3, 6, 4, token)
+		require.NoError(t, err)
+		pages = append(pages, p)
+
+		if token = p.Next(p.Len()); token == "" {
+			break
+		}
+	}
+
+	require.Len(t, pages, 3)
+	require.Equal(t, [][2]int{{-3, 0}, {1, 4}, {5, 6}},
+		[][2]int{{pages[0].From, pages[0].To}, {pages[1].From, pages[1].To}, {pages[2].From, pages[2].To}})
+
+	t.Run("partial page", func(t *testing.T) {
+		p, err := Paginate(0, 100, 10, "")
+		require.NoError(t, err)
+
+		next, err := Paginate(0, 100, 10, p.Next(3))
+		require.NoError(t, err)
+		require.Equal(t, 3, next.From)
+		require.Equal(t, 12, next.To)
+	})
+
+	t.Run("wide range", func(t *testing.T) {
+		p, err := Paginate(math.MinInt, math.MaxInt, 10, "")
+		require.NoError(t, err)
+		require.Equal(t, math.MinInt+9, p.To)
+
+		p, err = Paginate(math.MaxInt-5, math.MaxInt, 10, "")
+		require.NoError(t, err)
+		require.Equal(t, 6, p.Len())
+		require.Empty(t, p.Next(6))
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		p, err := Paginate(0, 100, 10, "")
+		require.NoError(t, err)
+
+		_, err = Paginate(0, 99, 10, p.Next(10))
+		require.ErrorIs(t, err, ErrTokenMismatch)
+
+		for _, token := range []string{"!", "AQ", encode(5, 1, 3), encode(0, 10, 11), p.Next(10) + "AA"} {
+			_, err = Paginate(0, 100, 10, token)
+			require.ErrorIs(t, err, ErrInvalidToken, token)
+		}
+	})
+}
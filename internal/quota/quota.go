@@ -0,0 +1,196 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrExceeded = errors.New("quota exceeded")
+
+// Limits задает суточные и месячные квоты клиента на время вычислений и объем отправленных данных. Нулевое значение
+// квоты снимает ограничение.
+type Limits struct {
+	DailyCompute   time.Duration
+	MonthlyCompute time.Duration
+	DailyBytes     int64
+	MonthlyBytes   int64
+}
+
+// Usage - потребление ресурсов клиентом.
+type Usage struct {
+	Compute time.Duration
+	Bytes   int64
+}
+
+// Remaining - оставшийся бюджет клиента с учетом суточных и месячных квот. Значение -1 означает отсутствие
+// ограничения.
+type Remaining struct {
+	Compute time.Duration
+	Bytes   int64
+}
+
+// Store хранит накопленное потребление ресурсов. Ключ включает клиента и учетный период, ttl задает время хранения
+// записи после окончания периода.
+type Store interface {
+	Get(ctx context.Context, key string) (Usage, error)
+	Add(ctx context.Context, key string, u Usage, ttl time.Duration) error
+	Close() error
+}
+
+// Manager учитывает потребление ресурсов клиентами и проверяет соблюдение квот.
+type Manager struct {
+	store Store
+	now   func() time.Time
+
+	mu      sync.RWMutex
+	def     Limits
+	clients map[string]Limits
+}
+
+// New создает новый объект типа Manager. Квоты def применяются к клиентам, для которых не заданы собственные квоты в
+// clients.
+func New(store Store, def Limits, clients map[string]Limits) *Manager {
+	return &Manager{
+		store:   store,
+		def:     def,
+		clients: clients,
+		now:     time.Now,
+	}
+}
+
+// Check проверяет, не исчерпал ли клиент client свои квоты, и возвращает оставшийся бюджет. При исчерпании любой из
+// квот возвращается ошибка ErrExceeded. При ошибке хранилища запрос допускается.
+func (m *Manager) Check(ctx context.Context, client string) (Remaining, error) {
+	limits := m.limits(client)
+	rem := Remaining{Compute: -1, Bytes: -1}
+
+	for _, p := range m.periods() {
+		computeLimit, bytesLimit := p.limits(limits)
+		if computeLimit == 0 && bytesLimit == 0 {
+			continue
+		}
+
+		u, err := m.store.Get(ctx, client+"|"+p.key)
+		if err != nil {
+			slog.ErrorContext(ctx, "quota store error", "error", err)
+			continue
+		}
+
+		if computeLimit > 0 {
+			rem.Compute = time.Duration(minRemaining(int64(rem.Compute), int64(computeLimit-u.Compute)))
+		}
+		if bytesLimit > 0 {
+			rem.Bytes = minRemaining(rem.Bytes, bytesLimit-u.Bytes)
+		}
+	}
+
+	if rem.Compute == 0 || rem.Bytes == 0 {
+		return rem, fmt.Errorf("%w: compute time remaining %v, bytes remaining %v", ErrExceeded,
+			rem.Compute, rem.Bytes)
+	}
+
+	return rem, nil
+}
+
+// Record добавляет потребление u к учету клиента client за текущие сутки и месяц.
+func (m *Manager) Record(ctx context.Context, client string, u Usage) {
+	if u.Compute == 0 && u.Bytes == 0 {
+		return
+	}
+
+	for _, p := range m.periods() {
+		if err := m.store.Add(ctx, client+"|"+p.key, u, p.ttl); err != nil {
+			slog.ErrorContext(ctx, "quota store error", "error", err)
+		}
+	}
+}
+
+// Close сохраняет накопленное потребление и закрывает хранилище.
+func (m *Manager) Close() error {
+	return m.store.Close()
+}
+
+// SetLimits заменяет квоты Manager. Может вызываться во время работы сервиса, накопленное потребление сохраняется.
+func (m *Manager) SetLimits(def Limits, clients map[string]Limits) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.def = def
+	m.clients = clients
+}
+
+func (m *Manager) limits(client string) Limits {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if l, ok := m.clients[client]; ok {
+		return l
+	}
+	return m.def
+}
+
+type period struct {
+	key    string
+	ttl    time.Duration
+	limits func(Limits) (time.Duration, int64)
+}
+
+// periods возвращает текущие учетные периоды (сутки и месяц по UTC).
+func (m *Manager) periods() []period {
+	now := m.now().UTC()
+	day := now.Truncate(24 * time.Hour)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	return []period{
+		{
+			key:    day.Format("2006-01-02"),
+			ttl:    day.AddDate(0, 0, 1).Sub(now) + time.Hour,
+			limits: func(l Limits) (time.Duration, int64) { return l.DailyCompute, l.DailyBytes },
+		},
+		{
+			key:    month.Format("2006-01"),
+			ttl:    month.AddDate(0, 1, 0).Sub(now) + time.Hour,
+			limits: func(l Limits) (time.Duration, int64) { return l.MonthlyCompute, l.MonthlyBytes },
+		},
+	}
+}
+
+// minRemaining возвращает меньший из остатков cur и rem, где отрицательный cur означает отсутствие ограничения.
+func minRemaining(cur, rem int64) int64 {
+	if rem < 0 {
+		rem = 0
+	}
+	if cur < 0 || rem < cur {
+		return rem
+	}
+	return cur
+}
+
+// Meter накапливает время вычислений в рамках одного запроса.
+type Meter struct {
+	compute int64
+}
+
+// Compute возвращает накопленное время вычислений.
+func (m *Meter) Compute() time.Duration {
+	return time.Duration(atomic.LoadInt64(&m.compute))
+}
+
+type ctxKey struct{}
+
+// NewContext возвращает копию ctx, содержащую m.
+func NewContext(ctx context.Context, m *Meter) context.Context {
+	return context.WithValue(ctx, ctxKey{}, m)
+}
+
+// RecordCompute добавляет время вычислений d к Meter из ctx. Если ctx не содержит Meter, вызов игнорируется.
+func RecordCompute(ctx context.Context, d time.Duration) {
+	if m, ok := ctx.Value(ctxKey{}).(*Meter); ok {
+		atomic.AddInt64(&m.compute, int64(d))
+	}
+}
@@ -0,0 +1,71 @@
+package quota
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+
+	m := New(store, Limits{DailyCompute: time.Second, MonthlyBytes: 100}, map[string]Limits{
+		"unlimited": {},
+	})
+	ctx := context.Background()
+
+	t.Run("remaining", func(t *testing.T) {
+		rem, err := m.Check(ctx, "client")
+		require.NoError(t, err)
+		require.Equal(t, Remaining{Compute: time.Second, Bytes: 100}, rem)
+
+		m.Record(ctx, "client", Usage{Compute: 400 * time.Millisecond, Bytes: 30})
+
+		rem, err = m.Check(ctx, "client")
+		require.NoError(t, err)
+		require.Equal(t, Remaining{Compute: 600 * time.Millisecond, Bytes: 70}, rem)
+	})
+
+	t.Run("exceeded", func(t *testing.T) {
+		m.Record(ctx, "client", Usage{Compute: time.Second})
+
+		rem, err := m.Check(ctx, "client")
+		require.ErrorIs(t, err, ErrExceeded)
+		require.Equal(t, time.Duration(0), rem.Compute)
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		m.Record(ctx, "unlimited", Usage{Compute: time.Hour, Bytes: 1000})
+
+		rem, err := m.Check(ctx, "unlimited")
+		require.NoError(t, err)
+		require.Equal(t, Remaining{Compute: -1, Bytes: -1}, rem)
+	})
+
+	t.Run("persistence", func(t *testing.T) {
+		require.NoError(t, m.Close())
+
+		store, err := NewFileStore(path)
+		require.NoError(t, err)
+		defer store.Close()
+
+		rem, err := New(store, m.def, m.clients).Check(ctx, "client")
+		require.ErrorIs(t, err, ErrExceeded)
+		require.Equal(t, int64(70), rem.Bytes)
+	})
+
+	t.Run("meter", func(t *testing.T) {
+		meter := &Meter{}
+		ctx := NewContext(ctx, meter)
+		RecordCompute(ctx, time.Second)
+		RecordCompute(ctx, time.Second)
+		require.Equal(t, 2*time.Second, meter.Compute())
+
+		RecordCompute(context.Background(), time.Second)
+	})
+}
@@ -0,0 +1,222 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	keyPrefix     = "quota:"
+	flushInterval = 10 * time.Second
+)
+
+// RedisStore хранит потребление ресурсов в Redis, что позволяет учитывать квоты на нескольких экземплярах сервиса.
+type RedisStore struct {
+	cl *redis.Client
+}
+
+// NewRedisStore создает новый объект типа RedisStore.
+func NewRedisStore(cl *redis.Client) *RedisStore {
+	return &RedisStore{cl: cl}
+}
+
+// Get реализует интерфейс Store.
+func (s *RedisStore) Get(ctx context.Context, key string) (Usage, error) {
+	vals, err := s.cl.HMGet(ctx, keyPrefix+key, "compute", "bytes").Result()
+	if err != nil {
+		return Usage{}, err
+	}
+
+	return Usage{
+		Compute: time.Duration(toInt64(vals[0])),
+		Bytes:   toInt64(vals[1]),
+	}, nil
+}
+
+// Add реализует интерфейс Store.
+func (s *RedisStore) Add(ctx context.Context, key string, u Usage, ttl time.Duration) error {
+	_, err := s.cl.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HIncrBy(ctx, keyPrefix+key, "compute", int64(u.Compute))
+		p.HIncrBy(ctx, keyPrefix+key, "bytes", u.Bytes)
+		p.Expire(ctx, keyPrefix+key, ttl)
+		return nil
+	})
+	return err
+}
+
+// Close реализует интерфейс Store.
+func (s *RedisStore) Close() error {
+	return nil
+}
+
+func toInt64(v interface{}) int64 {
+	str, ok := v.(string)
+	if !ok {
+		return 0
+	}
+
+	n, _ := strconv.ParseInt(str, 10, 64)
+	return n
+}
+
+type fileRecord struct {
+	Usage   Usage
+	Expires time.Time
+}
+
+// FileStore хранит потребление ресурсов в памяти и периодически сохраняет его в JSON файл, из которого оно
+// восстанавливается при перезапуске сервиса.
+type FileStore struct {
+	path    string
+	mu      sync.Mutex
+	records map[string]*fileRecord
+	dirty   bool
+	stopCh  chan struct{}
+	doneCh  chan struct{}
+}
+
+// NewFileStore создает новый объект типа FileStore и загружает сохраненное потребление из файла path, если он
+// существует.
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{
+		path:    path,
+		records: make(map[string]*fileRecord),
+		stopCh:  make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+
+	buf, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(buf, &s.records); err != nil {
+			return nil, err
+		}
+	}
+
+	go s.flushLoop()
+
+	return s, nil
+}
+
+// Get реализует интерфейс Store.
+func (s *FileStore) Get(_ context.Context, key string) (Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.records[key]
+	if !ok || time.Now().After(r.Expires) {
+		return Usage{}, nil
+	}
+
+	return r.Usage, nil
+}
+
+// Add реализует интерфейс Store.
+func (s *FileStore) Add(_ context.Context, key string, u Usage, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	r, ok := s.records[key]
+	if !ok || now.After(r.Expires) {
+		r = &fileRecord{}
+		s.records[key] = r
+	}
+
+	r.Usage.Compute += u.Compute
+	r.Usage.Bytes += u.Bytes
+	r.Expires = now.Add(ttl)
+	s.dirty = true
+
+	return nil
+}
+
+// Close останавливает периодическое сохранение и сохраняет накопленное потребление в файл.
+func (s *FileStore) Close() error {
+	close(s.stopCh)
+	<-s.doneCh
+
+	return s.flush()
+}
+
+func (s *FileStore) flushLoop() {
+	defer close(s.doneCh)
+
+	t := time.NewTicker(flushInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-t.C:
+			if err := s.flush(); err != nil {
+				slog.Error("quota store flush error", "error", err)
+			}
+		}
+	}
+}
+
+// flush сохраняет непросроченные записи в файл. Запись производится во временный файл с последующим
+// переименованием, чтобы не повредить сохраненные данные при сбое.
+func (s *FileStore) flush() (err error) {
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+
+	now := time.Now()
+	for key, r := range s.records {
+		if now.After(r.Expires) {
+			delete(s.records, key)
+		}
+	}
+
+	buf, err := json.Marshal(s.records)
+	s.dirty = false
+	s.mu.Unlock()
+
+	defer func() {
+		if err != nil {
+			s.mu.Lock()
+			s.dirty = true
+			s.mu.Unlock()
+		}
+	}()
+
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(buf); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
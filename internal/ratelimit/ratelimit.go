@@ -0,0 +1,190 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit задает параметры token bucket: Rate - скорость пополнения (запросов в секунду), Burst - емкость корзины.
+// Limit с нулевым Rate не ограничивает запросы.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Store хранит состояние корзин клиентов. Take забирает один токен из корзины key и возвращает true, если запрос
+// разрешен, либо false и время, через которое в корзине появится токен.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
+
+// AuthFailures - эндпоинт, в корзинах которого учитываются неудачные попытки аутентификации клиентов.
+const AuthFailures = "auth"
+
+// Limiter ограничивает частоту запросов клиентов отдельно для каждого эндпоинта.
+type Limiter struct {
+	store    Store
+	fallback Store
+	now      func() time.Time
+
+	mu        sync.RWMutex
+	def       Limit
+	endpoints map[string]Limit
+
+	blockedMu sync.Mutex
+	blocked   map[string]time.Time
+}
+
+// New создает новый объект типа Limiter. Лимит def применяется к эндпоинтам, для которых не задан собственный лимит в
+// endpoints. Если store возвращает ошибку (например, при недоступности Redis), Limiter использует локальное хранилище.
+func New(store Store, def Limit, endpoints map[string]Limit) *Limiter {
+	return &Limiter{
+		store:     store,
+		fallback:  NewMemoryStore(),
+		now:       time.Now,
+		def:       def,
+		endpoints: endpoints,
+		blocked:   make(map[string]time.Time),
+	}
+}
+
+// Allow проверяет, может ли клиент client выполнить запрос к эндпоинту endpoint. Если запрос не разрешен, Allow
+// возвращает время, через которое стоит повторить запрос.
+func (l *Limiter) Allow(ctx context.Context, endpoint, client string) (bool, time.Duration) {
+	l.mu.RLock()
+	limit, ok := l.endpoints[endpoint]
+	if !ok {
+		limit = l.def
+	}
+	l.mu.RUnlock()
+
+	if limit.Rate <= 0 {
+		return true, 0
+	}
+
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+
+	key := endpoint + "|" + client
+
+	allowed, retry, err := l.store.Take(ctx, key, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "rate limit store error", "error", err)
+		allowed, retry, _ = l.fallback.Take(ctx, key, limit)
+	}
+
+	return allowed, retry
+}
+
+// Fail учитывает неудачную попытку клиента client в корзине эндпоинта endpoint. Если корзина исчерпана, клиент
+// блокируется до появления в ней токена: Blocked не пропускает его попытки, не расходуя токены.
+func (l *Limiter) Fail(ctx context.Context, endpoint, client string) {
+	ok, retry := l.Allow(ctx, endpoint, client)
+	if ok {
+		return
+	}
+
+	l.blockedMu.Lock()
+	defer l.blockedMu.Unlock()
+
+	now := l.now()
+	for key, until := range l.blocked {
+		if !until.After(now) {
+			delete(l.blocked, key)
+		}
+	}
+	l.blocked[endpoint+"|"+client] = now.Add(retry)
+}
+
+// Blocked возвращает true и время до снятия блокировки, если клиент client исчерпал лимит неудачных попыток к
+// эндпоинту endpoint (см. Fail).
+func (l *Limiter) Blocked(endpoint, client string) (bool, time.Duration) {
+	l.blockedMu.Lock()
+	defer l.blockedMu.Unlock()
+
+	left := l.blocked[endpoint+"|"+client].Sub(l.now())
+	return left > 0, max(left, 0)
+}
+
+// SetLimits заменяет лимиты Limiter. Может вызываться во время работы сервиса, накопленное состояние корзин
+// сохраняется.
+func (l *Limiter) SetLimits(def Limit, endpoints map[string]Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.def = def
+	l.endpoints = endpoints
+}
+
+// RetryAfter округляет время ожидания до целого количества секунд (не меньше одной) для заголовка Retry-After.
+func RetryAfter(d time.Duration) int {
+	sec := int(math.Ceil(d.Seconds()))
+	if sec < 1 {
+		sec = 1
+	}
+	return sec
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// MemoryStore хранит корзины клиентов в памяти процесса.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+const pruneThreshold = 10000
+
+// NewMemoryStore создает новый объект типа MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take реализует интерфейс Store.
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	if len(s.buckets) >= pruneThreshold {
+		s.prune(now, limit)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+
+	wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	return false, wait, nil
+}
+
+// prune удаляет корзины, которые успели заполниться полностью: их состояние не отличается от состояния новой корзины.
+func (s *MemoryStore) prune(now time.Time, limit Limit) {
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*limit.Rate >= float64(limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	l := New(store, Limit{Rate: 1, Burst: 2}, map[string]Limit{
+		"/free": {},
+	})
+	ctx := context.Background()
+
+	t.Run("burst", func(t *testing.T) {
+		ok, _ := l.Allow(ctx, "/", "client1")
+		require.True(t, ok)
+		ok, _ = l.Allow(ctx, "/", "client1")
+		require.True(t, ok)
+
+		ok, retry := l.Allow(ctx, "/", "client1")
+		require.False(t, ok)
+		require.Equal(t, time.Second, retry)
+		require.Equal(t, 1, RetryAfter(retry))
+	})
+
+	t.Run("separate clients", func(t *testing.T) {
+		ok, _ := l.Allow(ctx, "/", "client2")
+		require.True(t, ok)
+	})
+
+	t.Run("refill", func(t *testing.T) {
+		now = now.Add(time.Second)
+		ok, _ := l.Allow(ctx, "/", "client1")
+		require.True(t, ok)
+		ok, _ = l.Allow(ctx, "/", "client1")
+		require.False(t, ok)
+	})
+
+	t.Run("unlimited endpoint", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			ok, _ := l.Allow(ctx, "/free", "client1")
+			require.True(t, ok)
+		}
+	})
+}
+
+func TestLimiterFail(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	l := New(store, Limit{Rate: 1, Burst: 2}, nil)
+	l.now = store.now
+	ctx := context.Background()
+
+	l.Fail(ctx, AuthFailures, "ip:1")
+	l.Fail(ctx, AuthFailures, "ip:1")
+	blocked, _ := l.Blocked(AuthFailures, "ip:1")
+	require.False(t, blocked)
+
+	l.Fail(ctx, AuthFailures, "ip:1")
+	blocked, retry := l.Blocked(AuthFailures, "ip:1")
+	require.True(t, blocked)
+	require.Equal(t, time.Second, retry)
+
+	blocked, _ = l.Blocked(AuthFailures, "ip:2")
+	require.False(t, blocked)
+
+	now = now.Add(time.Second)
+	blocked, _ = l.Blocked(AuthFailures, "ip:1")
+	require.False(t, blocked)
+}
@@ -1,37 +1,105 @@
 package rds
 
 import (
-	"log"
+	"context"
+	"fmt"
 	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dmitrykharchenko95/fibonacci/config"
+	"github.com/dmitrykharchenko95/fibonacci/internal/tracing"
 	"github.com/go-redis/redis/v8"
 )
 
-const defaultExpiration = 12 * time.Hour
-
 type Client struct {
-	Cl         *redis.Client
-	Expiration time.Duration
-	MaxErrors  int
+	Cl *redis.Client
+
+	expiration atomic.Int64
+	maxErrors  atomic.Int64
+
+	mu     sync.Mutex
+	closed bool
+	writes sync.WaitGroup
 }
 
 func NewRedisClient(cfg config.RedisConfig) *Client {
-	exp, err := time.ParseDuration(cfg.Expiration)
-	if err != nil {
-		log.Printf("parse redis Expiration fail: %v", err)
-		log.Printf("use default value - %v", defaultExpiration)
-		exp = defaultExpiration
+	cl := redis.NewClient(&redis.Options{
+		Addr:     net.JoinHostPort(cfg.Host, cfg.Port),
+		Password: "",
+		DB:       0,
+	})
+	cl.AddHook(tracing.RedisHook{})
+
+	return NewClient(cl, cfg.Expiration, cfg.MaxErrors)
+}
+
+// NewClient создает Client поверх cl. Значения кэшируются на время expiration, после maxErrors ошибок Redis
+// отключается (0 - Redis не используется).
+func NewClient(cl *redis.Client, expiration time.Duration, maxErrors int) *Client {
+	c := &Client{Cl: cl}
+	c.SetExpiration(expiration)
+	c.SetMaxErrors(maxErrors)
+	return c
+}
+
+// Expiration возвращает время хранения значений в кэше.
+func (c *Client) Expiration() time.Duration {
+	return time.Duration(c.expiration.Load())
+}
+
+// SetExpiration изменяет время хранения значений, записываемых в кэш. Может вызываться во время работы сервиса.
+func (c *Client) SetExpiration(expiration time.Duration) {
+	c.expiration.Store(int64(expiration))
+}
+
+// MaxErrors возвращает количество ошибок Redis, после которого кэширование отключается.
+func (c *Client) MaxErrors() int {
+	return int(c.maxErrors.Load())
+}
+
+// SetMaxErrors изменяет допустимое количество ошибок Redis. Может вызываться во время работы сервиса.
+func (c *Client) SetMaxErrors(maxErrors int) {
+	c.maxErrors.Store(int64(maxErrors))
+}
+
+// Set сохраняет значение val по ключу key на время Expiration. Запись не прерывается отменой ctx, чтобы уже вычисленное
+// значение попало в кэш и при остановке сервера. Close дожидается завершения начатых записей.
+func (c *Client) Set(ctx context.Context, key, val string) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return redis.ErrClosed
+	}
+	c.writes.Add(1)
+	c.mu.Unlock()
+	defer c.writes.Done()
+
+	return c.Cl.Set(context.WithoutCancel(ctx), key, val, c.Expiration()).Err()
+}
+
+// Close дожидается завершения начатых записей, но не дольше, чем до отмены ctx, и закрывает соединения с Redis.
+func (c *Client) Close(ctx context.Context) error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.writes.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("pending writes not flushed: %w", ctx.Err())
 	}
 
-	return &Client{
-		Cl: redis.NewClient(&redis.Options{
-			Addr:     net.JoinHostPort(cfg.Host, cfg.Port),
-			Password: "",
-			DB:       0,
-		}),
-		Expiration: exp,
-		MaxErrors:  cfg.MaxErrors,
+	if cerr := c.Cl.Close(); cerr != nil && err == nil {
+		err = cerr
 	}
+	return err
 }
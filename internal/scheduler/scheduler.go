@@ -0,0 +1,192 @@
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"math"
+	"math/bits"
+	"sync"
+)
+
+var (
+	ErrQueueFull    = errors.New("computation queue is full")
+	ErrQueueTimeout = errors.New("computation queue wait timeout")
+)
+
+// Scheduler ограничивает количество одновременно выполняемых вычислений. Запросы, для которых не нашлось свободного
+// слота, ожидают в очереди ограниченного размера, при этом более дешевые запросы получают слот раньше более дорогих.
+// При переполнении очереди новые запросы сразу отклоняются с ошибкой ErrQueueFull.
+type Scheduler struct {
+	mu        sync.Mutex
+	free      int
+	queueSize int
+	queue     waitQueue
+	seq       uint64
+}
+
+// New создает новый объект типа Scheduler с количеством слотов для вычислений workers и максимальным размером очереди
+// ожидания queueSize.
+func New(workers, queueSize int) *Scheduler {
+	return &Scheduler{
+		free:      workers,
+		queueSize: queueSize,
+	}
+}
+
+// Acquire занимает слот для вычисления стоимостью cost. При успешном выполнении возвращает функцию, которую необходимо
+// вызвать для освобождения слота. Если очередь ожидания заполнена, Acquire возвращает ошибку ErrQueueFull, если ctx
+// завершился до получения слота - ErrQueueTimeout. Вызов Acquire у nil Scheduler не ограничивает вычисления.
+func (s *Scheduler) Acquire(ctx context.Context, cost int64) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+
+	s.mu.Lock()
+	if s.free > 0 && s.queue.Len() == 0 {
+		s.free--
+		s.mu.Unlock()
+		return s.releaseFunc(), nil
+	}
+
+	if s.queue.Len() >= s.queueSize {
+		s.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+
+	w := &waiter{
+		cost:  cost,
+		seq:   s.seq,
+		ready: make(chan struct{}),
+	}
+	s.seq++
+	heap.Push(&s.queue, w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return s.releaseFunc(), nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if w.index >= 0 {
+			heap.Remove(&s.queue, w.index)
+			return nil, ErrQueueTimeout
+		}
+		// слот был передан одновременно с отменой ctx - возвращаем его следующему в очереди
+		s.release()
+		return nil, ErrQueueTimeout
+	}
+}
+
+// Stats возвращает количество свободных слотов и количество запросов в очереди ожидания.
+func (s *Scheduler) Stats() (free, queued int) {
+	if s == nil {
+		return 0, 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.free, s.queue.Len()
+}
+
+func (s *Scheduler) releaseFunc() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.release()
+			s.mu.Unlock()
+		})
+	}
+}
+
+// release передает освободившийся слот самому дешевому запросу из очереди или возвращает его в пул свободных слотов.
+// Должна вызываться под s.mu.
+func (s *Scheduler) release() {
+	if s.queue.Len() == 0 {
+		s.free++
+		return
+	}
+
+	w := heap.Pop(&s.queue).(*waiter)
+	close(w.ready)
+}
+
+// Cost оценивает стоимость вычисления чисел Фибоначчи с порядковыми номерами от x до y как произведение количества
+// чисел на наибольший по модулю порядковый номер. Стоимость, не помещающаяся в int64, ограничивается math.MaxInt64.
+func Cost(x, y int) int64 {
+	if x > y {
+		x, y = y, x
+	}
+
+	count := uint64(y) - uint64(x)
+	n := max(magnitude(x), magnitude(y))
+	if count >= math.MaxInt64 || n >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+
+	hi, lo := bits.Mul64(count+1, n+1)
+	if hi != 0 || lo > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(lo)
+}
+
+// AddCost возвращает сумму неотрицательных стоимостей a и b, ограниченную math.MaxInt64.
+func AddCost(a, b int64) int64 {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+// magnitude возвращает модуль n; в отличие от -n, корректен и для math.MinInt.
+func magnitude(n int) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
+
+type waiter struct {
+	cost  int64
+	seq   uint64
+	index int
+	ready chan struct{}
+}
+
+// waitQueue - очередь ожидания с приоритетом по стоимости вычисления, при равной стоимости - по времени поступления.
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waitQueue) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	w.index = -1
+	*q = old[:n-1]
+	return w
+}
@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler(t *testing.T) {
+	t.Run("queue full", func(t *testing.T) {
+		s := New(1, 1)
+
+		release, err := s.Acquire(context.Background(), 1)
+		require.NoError(t, err)
+		defer release()
+
+		go func() {
+			_, _ = s.Acquire(context.Background(), 1)
+		}()
+
+		require.Eventually(t, func() bool {
+			_, queued := s.Stats()
+			return queued == 1
+		}, time.Second, time.Millisecond)
+
+		_, err = s.Acquire(context.Background(), 1)
+		require.ErrorIs(t, err, ErrQueueFull)
+	})
+
+	t.Run("queue timeout", func(t *testing.T) {
+		s := New(1, 1)
+
+		release, err := s.Acquire(context.Background(), 1)
+		require.NoError(t, err)
+		defer release()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err = s.Acquire(ctx, 1)
+		require.ErrorIs(t, err, ErrQueueTimeout)
+
+		_, queued := s.Stats()
+		require.Equal(t, 0, queued)
+	})
+
+	t.Run("cheap first", func(t *testing.T) {
+		s := New(1, 10)
+
+		release, err := s.Acquire(context.Background(), 1)
+		require.NoError(t, err)
+
+		order := make(chan int64, 3)
+		for _, cost := range []int64{300, 200, 100} {
+			cost := cost
+			go func() {
+				r, err := s.Acquire(context.Background(), cost)
+				require.NoError(t, err)
+				order <- cost
+				r()
+			}()
+			require.Eventually(t, func() bool {
+				_, queued := s.Stats()
+				return queued == int(4-cost/100)
+			}, time.Second, time.Millisecond)
+		}
+
+		release()
+
+		require.Equal(t, int64(100), <-order)
+		require.Equal(t, int64(200), <-order)
+		require.Equal(t, int64(300), <-order)
+
+		require.Eventually(t, func() bool {
+			free, _ := s.Stats()
+			return free == 1
+		}, time.Second, time.Millisecond)
+	})
+}
+
+func TestCost(t *testing.T) {
+	require.Equal(t, int64(11*11), Cost(0, 10))
+	require.Equal(t, int64(11*11), Cost(0, -10))
+	require.Equal(t, int64(1), Cost(0, 0))
+
+	require.Equal(t, int64(math.MaxInt64), Cost(0, math.MaxInt))
+	require.Equal(t, int64(math.MaxInt64), Cost(math.MinInt, math.MinInt))
+	require.Equal(t, int64(math.MaxInt64), Cost(math.MinInt, math.MaxInt))
+	require.Equal(t, int64(math.MaxInt64), Cost(-1<<32, 1<<32))
+	require.Equal(t, int64(2)*(1<<40+2), Cost(1<<40, 1<<40+1))
+}
+
+func TestAddCost(t *testing.T) {
+	require.Equal(t, int64(3), AddCost(1, 2))
+	require.Equal(t, int64(math.MaxInt64), AddCost(math.MaxInt64, 1))
+	require.Equal(t, int64(math.MaxInt64), AddCost(math.MaxInt64-1, math.MaxInt64))
+}
@@ -0,0 +1,229 @@
+package adminserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/logger"
+	httpserver "github.com/dmitrykharchenko95/fibonacci/internal/server/http"
+	"github.com/dmitrykharchenko95/fibonacci/internal/service"
+)
+
+// Timeouter - сервер с изменяемым во время работы максимальным временем вычислений.
+type Timeouter interface {
+	Timeout() time.Duration
+	SetTimeout(timeout time.Duration)
+}
+
+// Server - служебный HTTP сервер с профилированием (net/http/pprof), статистикой среды выполнения и переключателями
+// параметров сервиса.
+type Server struct {
+	srv      *http.Server
+	addr     string
+	timeouts map[string]Timeouter
+	started  time.Time
+}
+
+// New создает новый объект типа Server, который будет прослушивать адрес host:port. Через timeouts по имени транспорта
+// (http, grpc) изменяется максимальное время вычислений соответствующего сервера. Middleware из mws применяются к
+// запросам в порядке передачи.
+func New(host, port string, timeouts map[string]Timeouter, mws ...httpserver.Middleware) *Server {
+	s := &Server{
+		addr:     net.JoinHostPort(host, port),
+		timeouts: timeouts,
+		started:  time.Now(),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/runtime", s.runtimeStats)
+	mux.HandleFunc("/toggles", s.toggles)
+
+	var h http.Handler = mux
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+
+	s.srv = &http.Server{
+		Addr:    s.addr,
+		Handler: h,
+	}
+	return s
+}
+
+// Start запускает служебный сервер.
+func (s *Server) Start() error {
+	slog.Info("start admin server", "addr", s.addr)
+
+	err := s.srv.ListenAndServe()
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Stop прекращает прием новых запросов и дожидается завершения обрабатываемых. После отмены ctx оставшиеся
+// соединения закрываются.
+func (s *Server) Stop(ctx context.Context) error {
+	slog.Info("stop admin server")
+
+	err := s.srv.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		slog.Warn("admin server grace period expired, closing connections")
+		return s.srv.Close()
+	}
+
+	return err
+}
+
+// RuntimeStats - статистика среды выполнения Go.
+type RuntimeStats struct {
+	Uptime       string `json:"uptime"`
+	GoVersion    string `json:"go_version"`
+	NumCPU       int    `json:"num_cpu"`
+	GOMAXPROCS   int    `json:"gomaxprocs"`
+	Goroutines   int    `json:"goroutines"`
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapSys      uint64 `json:"heap_sys"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	TotalAlloc   uint64 `json:"total_alloc"`
+	NumGC        uint32 `json:"num_gc"`
+	PauseTotalNs uint64 `json:"gc_pause_total_ns"`
+	LastGC       string `json:"last_gc,omitempty"`
+}
+
+func (s *Server) runtimeStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, httpserver.CodeMethodNotAllowed,
+			fmt.Errorf("unsupported method %v", r.Method))
+		return
+	}
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	st := RuntimeStats{
+		Uptime:       time.Since(s.started).Round(time.Second).String(),
+		GoVersion:    runtime.Version(),
+		NumCPU:       runtime.NumCPU(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		Goroutines:   runtime.NumGoroutine(),
+		HeapAlloc:    m.HeapAlloc,
+		HeapSys:      m.HeapSys,
+		HeapInuse:    m.HeapInuse,
+		HeapObjects:  m.HeapObjects,
+		TotalAlloc:   m.TotalAlloc,
+		NumGC:        m.NumGC,
+		PauseTotalNs: m.PauseTotalNs,
+	}
+	if m.LastGC != 0 {
+		st.LastGC = time.Unix(0, int64(m.LastGC)).UTC().Format(time.RFC3339Nano)
+	}
+
+	httpserver.WriteJSON(w, http.StatusOK, st)
+}
+
+// Toggles - параметры сервиса, изменяемые во время работы. При изменении незаданные поля не меняются.
+type Toggles struct {
+	Redis    *bool             `json:"redis,omitempty"`
+	LogLevel *string           `json:"log_level,omitempty"`
+	Timeouts map[string]string `json:"timeouts,omitempty"`
+}
+
+func (s *Server) toggles(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPatch:
+		var t Toggles
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			writeError(w, r, http.StatusBadRequest, httpserver.CodeInvalidArgument, fmt.Errorf("decode toggles: %w", err))
+			return
+		}
+		if err := s.apply(t); err != nil {
+			writeError(w, r, http.StatusBadRequest, httpserver.CodeInvalidArgument, err)
+			return
+		}
+		slog.InfoContext(r.Context(), "toggles changed", "toggles", s.current())
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, httpserver.CodeMethodNotAllowed,
+			fmt.Errorf("unsupported method %v", r.Method))
+		return
+	}
+
+	httpserver.WriteJSON(w, http.StatusOK, s.current())
+}
+
+// apply проверяет все значения t и только затем применяет их, чтобы ошибка в одном поле не приводила к частичному
+// изменению параметров.
+func (s *Server) apply(t Toggles) error {
+	if t.LogLevel != nil {
+		if _, err := logger.ParseLevel(*t.LogLevel); err != nil {
+			return err
+		}
+	}
+
+	timeouts := make(map[string]time.Duration, len(t.Timeouts))
+	for name, v := range t.Timeouts {
+		if _, ok := s.timeouts[name]; !ok {
+			return fmt.Errorf("unknown timeout %q", name)
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("timeout %q: %w", name, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("timeout %q should be positive", name)
+		}
+		timeouts[name] = d
+	}
+
+	if t.Redis != nil {
+		service.SetUseRedis(*t.Redis)
+	}
+	if t.LogLevel != nil {
+		if err := logger.SetLevel(*t.LogLevel); err != nil {
+			return err
+		}
+	}
+	for name, d := range timeouts {
+		s.timeouts[name].SetTimeout(d)
+	}
+
+	return nil
+}
+
+func (s *Server) current() Toggles {
+	redis := service.UseRedis()
+	lvl := logger.Level()
+
+	t := Toggles{
+		Redis:    &redis,
+		LogLevel: &lvl,
+		Timeouts: make(map[string]string, len(s.timeouts)),
+	}
+
+	for name, srv := range s.timeouts {
+		t.Timeouts[name] = srv.Timeout().String()
+	}
+
+	return t
+}
+
+// writeError записывает в w ошибку err с HTTP статусом status и кодом code в формате httpserver.ErrorResponse.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code string, err error) {
+	httpserver.WriteError(w, r, &httpserver.Error{Status: status, Code: code, Message: err.Error()})
+}
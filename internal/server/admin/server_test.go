@@ -0,0 +1,84 @@
+package adminserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/logger"
+	"github.com/dmitrykharchenko95/fibonacci/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+type timeouter struct {
+	timeout time.Duration
+}
+
+func (t *timeouter) Timeout() time.Duration {
+	return t.timeout
+}
+
+func (t *timeouter) SetTimeout(timeout time.Duration) {
+	t.timeout = timeout
+}
+
+func TestToggles(t *testing.T) {
+	httpT := &timeouter{timeout: time.Second}
+	s := New("127.0.0.1", "0", map[string]Timeouter{"http": httpT})
+
+	require.NoError(t, logger.SetLevel("info"))
+	defer logger.SetLevel("info")
+	service.SetUseRedis(true)
+	defer service.SetUseRedis(true)
+
+	do := func(method, body string) (*httptest.ResponseRecorder, Toggles) {
+		rec := httptest.NewRecorder()
+		s.srv.Handler.ServeHTTP(rec, httptest.NewRequest(method, "/toggles", strings.NewReader(body)))
+
+		var got Toggles
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		}
+		return rec, got
+	}
+
+	rec, got := do(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.True(t, *got.Redis)
+	require.Equal(t, "info", *got.LogLevel)
+	require.Equal(t, map[string]string{"http": "1s"}, got.Timeouts)
+
+	rec, got = do(http.MethodPut, `{"redis":false,"log_level":"debug","timeouts":{"http":"5s"}}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.False(t, *got.Redis)
+	require.Equal(t, "debug", *got.LogLevel)
+	require.Equal(t, 5*time.Second, httpT.Timeout())
+	require.False(t, service.UseRedis())
+
+	t.Run("invalid values are not applied", func(t *testing.T) {
+		rec, _ := do(http.MethodPatch, `{"redis":true,"timeouts":{"grpc":"1s"}}`)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		require.False(t, service.UseRedis())
+
+		rec, _ = do(http.MethodPatch, `{"log_level":"verbose","timeouts":{"http":"2s"}}`)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		require.Equal(t, 5*time.Second, httpT.Timeout())
+		require.Equal(t, "debug", logger.Level())
+	})
+}
+
+func TestRuntimeStats(t *testing.T) {
+	s := New("127.0.0.1", "0", nil)
+
+	rec := httptest.NewRecorder()
+	s.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/runtime", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var st RuntimeStats
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &st))
+	require.Positive(t, st.Goroutines)
+	require.Positive(t, st.HeapAlloc)
+}
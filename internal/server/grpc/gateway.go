@@ -0,0 +1,92 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/server/grpc/pb"
+	fibonacciv1 "github.com/dmitrykharchenko95/fibonacci/internal/server/grpc/pb/fibonacci/v1"
+	httpserver "github.com/dmitrykharchenko95/fibonacci/internal/server/http"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GatewayPrefix - префикс путей HTTP/JSON шлюза, заданных аннотациями google.api.http в proto файлах.
+const GatewayPrefix = "/rpc/"
+
+// Gateway возвращает HTTP обработчик, транслирующий запросы HTTP/JSON в вызовы сервисов сервера по аннотациям
+// google.api.http из proto файлов. Вызовы выполняются в процессе, минуя gRPC соединение, поэтому интерсепторы сервера к
+// ним не применяются: аутентификацию, ограничение частоты запросов и квоты обеспечивают Middleware HTTP сервера.
+// Ошибки возвращаются в формате httpserver.ErrorResponse.
+func (s *Server) Gateway(ctx context.Context) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{OrigName: true, EmitDefaults: true}),
+		runtime.WithProtoErrorHandler(gatewayError),
+	)
+
+	if err := pb.RegisterFibonacciHandlerServer(ctx, mux, s); err != nil {
+		return nil, err
+	}
+	if err := fibonacciv1.RegisterFibonacciServiceHandlerServer(ctx, mux, &serverV1{s: s}); err != nil {
+		return nil, err
+	}
+
+	return mux, nil
+}
+
+// gatewayError отвечает на запрос r к шлюзу ошибкой err, преобразуя gRPC статус в httpserver.Error.
+func gatewayError(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request,
+	err error) {
+	httpserver.WriteError(w, r, httpError(err))
+}
+
+// httpError преобразует gRPC статус err в ошибку HTTP API. Подробности google.rpc.BadRequest и google.rpc.RetryInfo
+// переносятся в httpserver.ErrorDetails.
+func httpError(err error) *httpserver.Error {
+	if errors.Is(err, runtime.ErrUnknownURI) {
+		return &httpserver.Error{Status: http.StatusNotFound, Code: httpserver.CodeNotFound, Message: "not found"}
+	}
+
+	st := status.Convert(err)
+	e := &httpserver.Error{Status: runtime.HTTPStatusFromCode(st.Code()), Message: st.Message()}
+
+	switch st.Code() {
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		e.Code = httpserver.CodeInvalidArgument
+	case codes.Unauthenticated:
+		e.Code = httpserver.CodeUnauthenticated
+	case codes.PermissionDenied:
+		e.Code = httpserver.CodePermissionDenied
+	case codes.NotFound:
+		e.Code = httpserver.CodeNotFound
+	case codes.ResourceExhausted:
+		e.Code = httpserver.CodeResourceExhausted
+	case codes.Unavailable:
+		e.Code = httpserver.CodeUnavailable
+	case codes.DeadlineExceeded:
+		e.Code = httpserver.CodeDeadlineExceeded
+	default:
+		e.Status, e.Code = http.StatusInternalServerError, httpserver.CodeInternal
+	}
+
+	var details httpserver.ErrorDetails
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.BadRequest:
+			if len(d.FieldViolations) > 0 {
+				details.Parameter = d.FieldViolations[0].Field
+			}
+		case *errdetails.RetryInfo:
+			details.RetryAfter = int(math.Ceil(d.RetryDelay.AsDuration().Seconds()))
+		}
+	}
+	if details != (httpserver.ErrorDetails{}) {
+		e.Details = &details
+	}
+
+	return e
+}
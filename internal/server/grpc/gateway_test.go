@@ -0,0 +1,162 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/rds"
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
+	httpserver "github.com/dmitrykharchenko95/fibonacci/internal/server/http"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGateway(t *testing.T) {
+	rdb := rds.NewClient(redis.NewClient(&redis.Options{}), time.Hour, 0)
+	s := New(nil, 200*time.Millisecond, rdb, scheduler.New(1, 10), nil)
+
+	gw, err := s.Gateway(context.Background())
+	require.NoError(t, err)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	t.Run("batch", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc/v1/fibonacci/batch",
+			strings.NewReader(`{"queries": [{"id": "a", "n": "10"}, {"range": {"from": "5", "to": "1"}}]}`)))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Results []struct {
+				ID    string `json:"id"`
+				Items []struct {
+					Decimal string `json:"decimal"`
+				} `json:"items"`
+				Error *struct {
+					Code int `json:"code"`
+				} `json:"error"`
+			} `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Results, 2)
+		require.Equal(t, "a", resp.Results[0].ID)
+		require.Equal(t, "55", resp.Results[0].Items[0].Decimal)
+		require.Nil(t, resp.Results[0].Error)
+		require.Equal(t, 3, resp.Results[1].Error.Code)
+	})
+
+	t.Run("number", func(t *testing.T) {
+		w := get("/rpc/v1/fibonacci/10")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"item":{"index":"10","decimal":"55","magnitude":null,"negative":false,"digits":0,`+
+			`"source":"SOURCE_UNSPECIFIED","compute_time":null},"stats":null}`, w.Body.String())
+
+		w = get("/rpc/v1/fibonacci/10?verbose=true")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Item struct {
+				Digits int    `json:"digits"`
+				Source string `json:"source"`
+			} `json:"item"`
+			Stats struct {
+				Count   string `json:"count"`
+				Elapsed string `json:"elapsed"`
+			} `json:"stats"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Equal(t, 2, resp.Item.Digits)
+		require.Equal(t, "SOURCE_COMPUTED", resp.Item.Source)
+		require.Equal(t, "1", resp.Stats.Count)
+		require.NotEmpty(t, resp.Stats.Elapsed)
+	})
+
+	t.Run("range", func(t *testing.T) {
+		w := get("/rpc/v1/fibonacci?from=-2&to=1&encoding=ENCODING_BYTES")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Items []struct {
+				Index     string `json:"index"`
+				Magnitude []byte `json:"magnitude"`
+				Negative  bool   `json:"negative"`
+			} `json:"items"`
+			Partial  bool   `json:"partial"`
+			Expected string `json:"expected"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.False(t, resp.Partial)
+		require.Equal(t, "4", resp.Expected)
+		require.Len(t, resp.Items, 4)
+		require.Equal(t, "-2", resp.Items[0].Index)
+		require.True(t, resp.Items[0].Negative)
+		require.Equal(t, []byte{1}, resp.Items[0].Magnitude)
+	})
+
+	t.Run("indices", func(t *testing.T) {
+		w := get("/rpc/v1/indices?list.indices=10&list.indices=1")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"items":[`+
+			`{"index":"10","decimal":"55","magnitude":null,"negative":false,"digits":0,"source":"SOURCE_UNSPECIFIED",`+
+			`"compute_time":null},`+
+			`{"index":"1","decimal":"1","magnitude":null,"negative":false,"digits":0,"source":"SOURCE_UNSPECIFIED",`+
+			`"compute_time":null}],"partial":false,"expected":"2","stats":null}`,
+			w.Body.String())
+	})
+
+	t.Run("legacy", func(t *testing.T) {
+		w := get("/rpc/fibonacci?x=10&y=8")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "true", w.Header().Get("Grpc-Metadata-Deprecation"))
+		require.JSONEq(t, `{"data":["21","34","55"],"err":""}`, w.Body.String())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			target string
+			status int
+			want   httpserver.ErrorBody
+		}{
+			{
+				target: "/rpc/v1/fibonacci?from=5&to=1",
+				status: http.StatusBadRequest,
+				want: httpserver.ErrorBody{Code: httpserver.CodeInvalidArgument, Message: "to: must not be less than from",
+					Details: &httpserver.ErrorDetails{Parameter: "to"}},
+			},
+			{
+				target: "/rpc/v1/fibonacci/100000000",
+				status: http.StatusGatewayTimeout,
+				want:   httpserver.ErrorBody{Code: httpserver.CodeDeadlineExceeded},
+			},
+			{
+				target: "/rpc/v2/fibonacci",
+				status: http.StatusNotFound,
+				want:   httpserver.ErrorBody{Code: httpserver.CodeNotFound, Message: "not found"},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.target, func(t *testing.T) {
+				w := get(tt.target)
+				require.Equal(t, tt.status, w.Code)
+
+				var resp httpserver.ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				require.Equal(t, tt.want.Code, resp.Error.Code)
+				if tt.want.Message != "" {
+					require.Equal(t, tt.want.Message, resp.Error.Message)
+				}
+				require.Equal(t, tt.want.Details, resp.Error.Details)
+			})
+		}
+	})
+}
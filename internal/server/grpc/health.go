@@ -0,0 +1,103 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/health"
+	"github.com/dmitrykharchenko95/fibonacci/internal/server/grpc/pb"
+	fibonacciv1 "github.com/dmitrykharchenko95/fibonacci/internal/server/grpc/pb/fibonacci/v1"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// healthWatchInterval - периодичность проверки готовности для подписчиков Watch.
+const healthWatchInterval = time.Second
+
+// healthServer реализует протокол grpc.health.v1.Health поверх health.Checker. Сервис "" отражает готовность сервера в
+// целом.
+type healthServer struct {
+	checker  *health.Checker
+	services map[string]bool
+	healthpb.UnimplementedHealthServer
+}
+
+// RegisterHealth регистрирует на сервере сервис grpc.health.v1.Health, сообщающий статус SERVING, пока c считает сервер
+// готовым. Должен вызываться до Start.
+func (s *Server) RegisterHealth(c *health.Checker) {
+	healthpb.RegisterHealthServer(s.srv, &healthServer{
+		checker: c,
+		services: map[string]bool{
+			"":                                   true,
+			pb.Fibonacci_ServiceDesc.ServiceName: true,
+			fibonacciv1.FibonacciService_ServiceDesc.ServiceName: true,
+		},
+	})
+}
+
+func (h *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if !h.services[req.Service] {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.Service)
+	}
+	return &healthpb.HealthCheckResponse{Status: h.status(ctx)}, nil
+}
+
+// Watch сообщает статус сервиса при подписке и при каждом его изменении. При начале остановки сервера подписчику
+// отправляется NOT_SERVING и подписка завершается: иначе GracefulStop ждал бы, пока клиент сам закроет поток.
+func (h *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+
+	known := h.services[req.Service]
+	last := healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	if known {
+		last = h.status(ctx)
+	}
+	if err := stream.Send(&healthpb.HealthCheckResponse{Status: last}); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(healthWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		if h.checker.Draining() {
+			if !known || last == healthpb.HealthCheckResponse_NOT_SERVING {
+				return nil
+			}
+			return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING})
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+		if !known {
+			continue
+		}
+
+		st := h.status(ctx)
+		if st == last {
+			continue
+		}
+		last = st
+		if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+			return err
+		}
+	}
+}
+
+func (h *healthServer) status(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	if h.checker.Ready(ctx).Ready {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
+
+// healthMethod сообщает, относится ли метод к протоколу проверки здоровья. Такие вызовы не требуют аутентификации и не
+// учитываются в лимитах и квотах.
+func healthMethod(method string) bool {
+	return strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/")
+}
@@ -0,0 +1,59 @@
+package grpcserver
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/health"
+	"github.com/dmitrykharchenko95/fibonacci/internal/rds"
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealthWatchDrain(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "grpc.sock")
+	rdb := rds.NewClient(redis.NewClient(&redis.Options{}), time.Hour, 0)
+	s := New([]string{"unix://" + sock}, 200*time.Millisecond, rdb, scheduler.New(1, 10), nil)
+
+	checker := health.New(time.Second)
+	checker.WarmedUp()
+	s.RegisterHealth(checker)
+
+	go func() {
+		require.NoError(t, s.Start())
+	}()
+
+	conn, err := grpc.Dial("unix://"+sock, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx := context.Background()
+	stream, err := healthpb.NewHealthClient(conn).Watch(ctx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(true))
+	require.NoError(t, err)
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	checker.Drain()
+
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	_, err = stream.Recv()
+	require.ErrorIs(t, err, io.EOF)
+
+	// Подписка завершена, поэтому остановка не ждет, пока клиент закроет поток.
+	stopCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	s.Stop(stopCtx)
+	require.NoError(t, stopCtx.Err())
+}
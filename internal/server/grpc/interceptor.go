@@ -0,0 +1,423 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/auth"
+	"github.com/dmitrykharchenko95/fibonacci/internal/logger"
+	"github.com/dmitrykharchenko95/fibonacci/internal/metrics"
+	"github.com/dmitrykharchenko95/fibonacci/internal/quota"
+	"github.com/dmitrykharchenko95/fibonacci/internal/ratelimit"
+	"github.com/dmitrykharchenko95/fibonacci/internal/tracing"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// MetricsUnaryInterceptor возвращает интерсептор, собирающий метрики вызовов: количество, время обработки и размер
+// ответов по методу и коду статуса.
+func MetricsUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err).String()
+		metrics.Requests.WithLabelValues("grpc", info.FullMethod, code).Inc()
+		metrics.RequestDuration.WithLabelValues("grpc", code).Observe(time.Since(start).Seconds())
+		if msg, ok := resp.(proto.Message); ok && err == nil {
+			metrics.ResponseSize.WithLabelValues("grpc").Observe(float64(proto.Size(msg)))
+		}
+
+		return resp, err
+	}
+}
+
+// MetricsStreamInterceptor - аналог MetricsUnaryInterceptor для стриминговых методов. Размер ответов не учитывается.
+func MetricsStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		err := handler(srv, ss)
+
+		code := status.Code(err).String()
+		metrics.Requests.WithLabelValues("grpc", info.FullMethod, code).Inc()
+		metrics.RequestDuration.WithLabelValues("grpc", code).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
+
+const (
+	// requestIDKey - ключ метаданных с идентификатором запроса.
+	requestIDKey = "x-request-id"
+	// logLevelKey - ключ метаданных, значение debug которого включает отладочное логирование вызова.
+	logLevelKey = "x-log-level"
+)
+
+// RequestIDUnaryInterceptor возвращает интерсептор, сохраняющий в контексте вызова идентификатор запроса из метаданных
+// x-request-id или сгенерированный новый. Идентификатор возвращается клиенту в заголовке ответа и добавляется во все
+// записи лога, относящиеся к вызову. Метаданные x-log-level: debug включают отладочное логирование для вызова.
+func RequestIDUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(requestContext(ctx), req)
+	}
+}
+
+// RequestIDStreamInterceptor - аналог RequestIDUnaryInterceptor для стриминговых методов.
+func RequestIDStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: requestContext(ss.Context())})
+	}
+}
+
+func requestContext(ctx context.Context) context.Context {
+	id := metadataValue(ctx, requestIDKey)
+	if id == "" {
+		id = logger.NewRequestID()
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id)); err != nil {
+		slog.ErrorContext(ctx, "can not set request id header", "error", err)
+	}
+
+	ctx = logger.WithRequestID(ctx, id)
+	if strings.EqualFold(metadataValue(ctx, logLevelKey), "debug") {
+		ctx = logger.WithDebug(ctx)
+	}
+	return ctx
+}
+
+// TracingUnaryInterceptor возвращает интерсептор, создающий серверный спан для каждого вызова. Контекст трассировки,
+// переданный клиентом в метаданных traceparent и tracestate, используется как родительский.
+func TracingUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, span := startSpan(ctx, info.FullMethod)
+		defer span.End()
+
+		resp, err := handler(ctx, req)
+		endSpan(span, err)
+
+		return resp, err
+	}
+}
+
+// TracingStreamInterceptor - аналог TracingUnaryInterceptor для стриминговых методов.
+func TracingStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := startSpan(ss.Context(), info.FullMethod)
+		defer span.End()
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		endSpan(span, err)
+
+		return err
+	}
+}
+
+func startSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = tracing.Extract(ctx, metadataCarrier(md))
+
+	service, name := method, method
+	if i := strings.LastIndex(method, "/"); i > 0 {
+		service, name = strings.TrimPrefix(method[:i], "/"), method[i+1:]
+	}
+
+	return tracing.Start(ctx, strings.TrimPrefix(method, "/"), trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.RPCSystemKey.String("grpc"),
+			semconv.RPCServiceKey.String(service),
+			semconv.RPCMethodKey.String(name),
+		))
+}
+
+func endSpan(span trace.Span, err error) {
+	code := status.Code(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int64(int64(code)))
+	if err != nil {
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+}
+
+// metadataCarrier позволяет читать контекст трассировки из метаданных gRPC.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if vals := metadata.MD(c).Get(key); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// AuthUnaryInterceptor возвращает интерсептор, проверяющий учетные данные клиента: API ключ из метаданных apiKeyHeader
+// или bearer токен из метаданных authorization. Identity клиента сохраняется в контексте вызова (auth.FromContext). На
+// вызовы с неверными или отсутствующими учетными данными сервер отвечает кодом UNAUTHENTICATED. Если lim не nil,
+// неудачные попытки учитываются по IP адресу клиента в лимите эндпоинта ratelimit.AuthFailures; после его исчерпания
+// вызовы с этого адреса отклоняются без проверки кодом RESOURCE_EXHAUSTED с подробностями google.rpc.RetryInfo.
+func AuthUnaryInterceptor(g *auth.Guard, apiKeyHeader string, lim *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		if healthMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, err := authenticate(ctx, g, apiKeyHeader, lim)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// AuthStreamInterceptor - аналог AuthUnaryInterceptor для стриминговых методов.
+func AuthStreamInterceptor(g *auth.Guard, apiKeyHeader string, lim *ratelimit.Limiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if healthMethod(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		ctx, err := authenticate(ss.Context(), g, apiKeyHeader, lim)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func authenticate(ctx context.Context, g *auth.Guard, apiKeyHeader string, lim *ratelimit.Limiter) (context.Context,
+	error) {
+	ip := "ip:" + clientIP(ctx)
+	if lim != nil {
+		if blocked, retry := lim.Blocked(ratelimit.AuthFailures, ip); blocked {
+			slog.WarnContext(ctx, "authentication throttled", "client", getClientName(ctx))
+			return nil, retryLater(ctx, retry, "too many failed authentication attempts")
+		}
+	}
+
+	cred := auth.Credentials{
+		APIKey: metadataValue(ctx, apiKeyHeader),
+	}
+	if h := metadataValue(ctx, "authorization"); len(h) > 7 && strings.EqualFold(h[:7], "bearer ") {
+		cred.Bearer = h[7:]
+	}
+
+	id, err := g.Authenticate(ctx, cred)
+	if err != nil {
+		if lim != nil {
+			lim.Fail(ctx, ratelimit.AuthFailures, ip)
+		}
+		slog.WarnContext(ctx, "authentication failed", "client", getClientName(ctx), "error", err)
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return auth.NewContext(ctx, id), nil
+}
+
+// authStatus преобразует ошибку авторизации в gRPC статус: UNAUTHENTICATED для анонимного клиента и PERMISSION_DENIED
+// для клиента без нужной области доступа.
+func authStatus(err error) error {
+	if errors.Is(err, auth.ErrForbidden) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Error(codes.Unauthenticated, err.Error())
+}
+
+// serverStream подменяет контекст grpc.ServerStream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// RateLimitUnaryInterceptor возвращает интерсептор, ограничивающий частоту вызовов каждого метода клиентом. Клиент
+// определяется по Identity из контекста вызова, API ключу из метаданных keyHeader, если guard подтверждает его, или IP
+// адресу. Ключи, не прошедшие проверку (в том числе при nil guard), не учитываются. На вызовы сверх лимита сервер
+// отвечает кодом RESOURCE_EXHAUSTED с подробностями google.rpc.RetryInfo.
+func RateLimitUnaryInterceptor(lim *ratelimit.Limiter, keyHeader string,
+	guard *auth.Guard) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		if err := rateLimit(ctx, lim, keyHeader, guard, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// RateLimitStreamInterceptor - аналог RateLimitUnaryInterceptor для стриминговых методов.
+func RateLimitStreamInterceptor(lim *ratelimit.Limiter, keyHeader string,
+	guard *auth.Guard) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := rateLimit(ss.Context(), lim, keyHeader, guard, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func rateLimit(ctx context.Context, lim *ratelimit.Limiter, keyHeader string, guard *auth.Guard, method string) error {
+	if healthMethod(method) {
+		return nil
+	}
+
+	client := clientKey(ctx, keyHeader, guard)
+
+	ok, retry := lim.Allow(ctx, method, client)
+	if ok {
+		return nil
+	}
+
+	slog.WarnContext(ctx, "rate limit exceeded", "client", client, "method", method)
+	return retryLater(ctx, retry, "rate limit exceeded")
+}
+
+// retryLater возвращает ошибку RESOURCE_EXHAUSTED с текстом msg и подробностями google.rpc.RetryInfo и передает время
+// ожидания retry в метаданных retry-after.
+func retryLater(ctx context.Context, retry time.Duration, msg string) error {
+	err := grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(ratelimit.RetryAfter(retry))))
+	if err != nil {
+		slog.ErrorContext(ctx, "can not set retry-after header", "error", err)
+	}
+
+	return withDetails(status.Newf(codes.ResourceExhausted, "%s, retry after %v", msg, retry),
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(retry)})
+}
+
+// QuotaUnaryInterceptor возвращает интерсептор, учитывающий время вычислений и объем отправленных данных клиента и
+// отклоняющий вызовы клиентов, исчерпавших квоты, с кодом RESOURCE_EXHAUSTED и подробностями google.rpc.QuotaFailure.
+// Оставшийся бюджет передается в метаданных x-quota-compute-remaining (в миллисекундах) и x-quota-bytes-remaining.
+func QuotaUnaryInterceptor(m *quota.Manager) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		if healthMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		client := quotaKey(ctx)
+
+		rem, err := m.Check(ctx, client)
+
+		md := metadata.MD{}
+		if rem.Compute >= 0 {
+			md.Set("x-quota-compute-remaining", strconv.FormatInt(rem.Compute.Milliseconds(), 10))
+		}
+		if rem.Bytes >= 0 {
+			md.Set("x-quota-bytes-remaining", strconv.FormatInt(rem.Bytes, 10))
+		}
+		if len(md) > 0 {
+			if err := grpc.SetHeader(ctx, md); err != nil {
+				slog.ErrorContext(ctx, "can not set quota header", "error", err)
+			}
+		}
+
+		if err != nil {
+			slog.WarnContext(ctx, "quota exceeded", "client", getClientName(ctx), "error", err)
+			return nil, withDetails(status.New(codes.ResourceExhausted, err.Error()),
+				&errdetails.QuotaFailure{Violations: []*errdetails.QuotaFailure_Violation{
+					{Subject: client, Description: err.Error()},
+				}})
+		}
+
+		meter := &quota.Meter{}
+		resp, err := handler(quota.NewContext(ctx, meter), req)
+
+		u := quota.Usage{Compute: meter.Compute()}
+		if msg, ok := resp.(proto.Message); ok {
+			u.Bytes = int64(proto.Size(msg))
+		}
+		m.Record(ctx, client, u)
+
+		return resp, err
+	}
+}
+
+// quotaKey возвращает идентификатор клиента для учета квот: имя аутентифицированного клиента или IP адрес
+// анонимного клиента.
+func quotaKey(ctx context.Context) string {
+	if id := auth.FromContext(ctx); id != nil && id.Subject != "" {
+		return id.Subject
+	}
+
+	return "ip:" + clientIP(ctx)
+}
+
+// clientIP возвращает IP адрес клиента без порта или "unknown", если адрес неизвестен.
+func clientIP(ctx context.Context) string {
+	addr, err := getClientIP(ctx)
+	if err != nil {
+		return "unknown"
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// clientKey возвращает идентификатор клиента: имя аутентифицированного клиента, имя клиента с API ключом из метаданных
+// keyHeader, подтвержденным guard, имя из клиентского сертификата или IP адрес клиента. Сам ключ в идентификатор не
+// попадает.
+func clientKey(ctx context.Context, keyHeader string, guard *auth.Guard) string {
+	if id := auth.FromContext(ctx); id != nil && id.Subject != "" {
+		return "id:" + id.Subject
+	}
+
+	if id := guard.KeyIdentity(ctx, metadataValue(ctx, keyHeader)); id != nil {
+		return "id:" + id.Subject
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		if name := getPeerName(p); name != "" {
+			return "peer:" + name
+		}
+	}
+
+	return "ip:" + clientIP(ctx)
+}
+
+// metadataValue возвращает первое значение метаданных запроса с ключом key.
+func metadataValue(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || key == "" {
+		return ""
+	}
+
+	if vals := md.Get(strings.ToLower(key)); len(vals) > 0 {
+		return vals[0]
+	}
+
+	return ""
+}
@@ -0,0 +1,235 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.6.1
+// source: fibonacci/v1/fibonacci.proto
+
+package fibonacciv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// FibonacciServiceClient is the client API for FibonacciService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type FibonacciServiceClient interface {
+	// GetNumber returns the Fibonacci number with index n.
+	GetNumber(ctx context.Context, in *GetNumberRequest, opts ...grpc.CallOption) (*GetNumberResponse, error)
+	// GetRange returns the Fibonacci numbers with indices from..to inclusive, one page at a time. Each page is computed
+	// separately, so a range of any width can be walked with next_page_token. When the server timeout expires, the
+	// numbers of the page computed so far are returned with partial set and next_page_token pointing at the rest.
+	GetRange(ctx context.Context, in *GetRangeRequest, opts ...grpc.CallOption) (*GetRangeResponse, error)
+	// GetIndices returns the Fibonacci numbers for a set of indices: an explicit list or an arithmetic progression,
+	// in the requested order. Each number is computed in O(log n) multiplications, without the numbers in between.
+	// When the server timeout expires, the numbers computed so far are returned with partial set.
+	GetIndices(ctx context.Context, in *GetIndicesRequest, opts ...grpc.CallOption) (*GetIndicesResponse, error)
+	// GetBatch returns the Fibonacci numbers for several indices and ranges at once. Overlapping queries are computed
+	// once, all of them within a single server timeout. Errors of separate queries are returned in
+	// BatchResult.error, the call fails only if the whole request is invalid or rejected. Each range and all queries
+	// together may request at most the server page size limit of numbers (INVALID_ARGUMENT otherwise).
+	GetBatch(ctx context.Context, in *GetBatchRequest, opts ...grpc.CallOption) (*GetBatchResponse, error)
+}
+
+type fibonacciServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFibonacciServiceClient(cc grpc.ClientConnInterface) FibonacciServiceClient {
+	return &fibonacciServiceClient{cc}
+}
+
+func (c *fibonacciServiceClient) GetNumber(ctx context.Context, in *GetNumberRequest, opts ...grpc.CallOption) (*GetNumberResponse, error) {
+	out := new(GetNumberResponse)
+	err := c.cc.Invoke(ctx, "/fibonacci.v1.FibonacciService/GetNumber", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fibonacciServiceClient) GetRange(ctx context.Context, in *GetRangeRequest, opts ...grpc.CallOption) (*GetRangeResponse, error) {
+	out := new(GetRangeResponse)
+	err := c.cc.Invoke(ctx, "/fibonacci.v1.FibonacciService/GetRange", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fibonacciServiceClient) GetIndices(ctx context.Context, in *GetIndicesRequest, opts ...grpc.CallOption) (*GetIndicesResponse, error) {
+	out := new(GetIndicesResponse)
+	err := c.cc.Invoke(ctx, "/fibonacci.v1.FibonacciService/GetIndices", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fibonacciServiceClient) GetBatch(ctx context.Context, in *GetBatchRequest, opts ...grpc.CallOption) (*GetBatchResponse, error) {
+	out := new(GetBatchResponse)
+	err := c.cc.Invoke(ctx, "/fibonacci.v1.FibonacciService/GetBatch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FibonacciServiceServer is the server API for FibonacciService service.
+// All implementations must embed UnimplementedFibonacciServiceServer
+// for forward compatibility
+type FibonacciServiceServer interface {
+	// GetNumber returns the Fibonacci number with index n.
+	GetNumber(context.Context, *GetNumberRequest) (*GetNumberResponse, error)
+	// GetRange returns the Fibonacci numbers with indices from..to inclusive, one page at a time. Each page is computed
+	// separately, so a range of any width can be walked with next_page_token. When the server timeout expires, the
+	// numbers of the page computed so far are returned with partial set and next_page_token pointing at the rest.
+	GetRange(context.Context, *GetRangeRequest) (*GetRangeResponse, error)
+	// GetIndices returns the Fibonacci numbers for a set of indices: an explicit list or an arithmetic progression,
+	// in the requested order. Each number is computed in O(log n) multiplications, without the numbers in between.
+	// When the server timeout expires, the numbers computed so far are returned with partial set.
+	GetIndices(context.Context, *GetIndicesRequest) (*GetIndicesResponse, error)
+	// GetBatch returns the Fibonacci numbers for several indices and ranges at once. Overlapping queries are computed
+	// once, all of them within a single server timeout. Errors of separate queries are returned in
+	// BatchResult.error, the call fails only if the whole request is invalid or rejected. Each range and all queries
+	// together may request at most the server page size limit of numbers (INVALID_ARGUMENT otherwise).
+	GetBatch(context.Context, *GetBatchRequest) (*GetBatchResponse, error)
+	mustEmbedUnimplementedFibonacciServiceServer()
+}
+
+// UnimplementedFibonacciServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedFibonacciServiceServer struct {
+}
+
+func (UnimplementedFibonacciServiceServer) GetNumber(context.Context, *GetNumberRequest) (*GetNumberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNumber not implemented")
+}
+func (UnimplementedFibonacciServiceServer) GetRange(context.Context, *GetRangeRequest) (*GetRangeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRange not implemented")
+}
+func (UnimplementedFibonacciServiceServer) GetIndices(context.Context, *GetIndicesRequest) (*GetIndicesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetIndices not implemented")
+}
+func (UnimplementedFibonacciServiceServer) GetBatch(context.Context, *GetBatchRequest) (*GetBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBatch not implemented")
+}
+func (UnimplementedFibonacciServiceServer) mustEmbedUnimplementedFibonacciServiceServer() {}
+
+// UnsafeFibonacciServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FibonacciServiceServer will
+// result in compilation errors.
+type UnsafeFibonacciServiceServer interface {
+	mustEmbedUnimplementedFibonacciServiceServer()
+}
+
+func RegisterFibonacciServiceServer(s grpc.ServiceRegistrar, srv FibonacciServiceServer) {
+	s.RegisterService(&FibonacciService_ServiceDesc, srv)
+}
+
+func _FibonacciService_GetNumber_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNumberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FibonacciServiceServer).GetNumber(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/fibonacci.v1.FibonacciService/GetNumber",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FibonacciServiceServer).GetNumber(ctx, req.(*GetNumberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FibonacciService_GetRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FibonacciServiceServer).GetRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/fibonacci.v1.FibonacciService/GetRange",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FibonacciServiceServer).GetRange(ctx, req.(*GetRangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FibonacciService_GetIndices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetIndicesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FibonacciServiceServer).GetIndices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/fibonacci.v1.FibonacciService/GetIndices",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FibonacciServiceServer).GetIndices(ctx, req.(*GetIndicesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FibonacciService_GetBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FibonacciServiceServer).GetBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/fibonacci.v1.FibonacciService/GetBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FibonacciServiceServer).GetBatch(ctx, req.(*GetBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// FibonacciService_ServiceDesc is the grpc.ServiceDesc for FibonacciService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FibonacciService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "fibonacci.v1.FibonacciService",
+	HandlerType: (*FibonacciServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetNumber",
+			Handler:    _FibonacciService_GetNumber_Handler,
+		},
+		{
+			MethodName: "GetRange",
+			Handler:    _FibonacciService_GetRange_Handler,
+		},
+		{
+			MethodName: "GetIndices",
+			Handler:    _FibonacciService_GetIndices_Handler,
+		},
+		{
+			MethodName: "GetBatch",
+			Handler:    _FibonacciService_GetBatch_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "fibonacci/v1/fibonacci.proto",
+}
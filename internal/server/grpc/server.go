@@ -2,54 +2,127 @@ package grpcserver
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
+	"sync/atomic"
 	"time"
 
+	"github.com/dmitrykharchenko95/fibonacci/internal/auth"
+	"github.com/dmitrykharchenko95/fibonacci/internal/compute"
+	"github.com/dmitrykharchenko95/fibonacci/internal/listener"
+	"github.com/dmitrykharchenko95/fibonacci/internal/pagination"
 	"github.com/dmitrykharchenko95/fibonacci/internal/rds"
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
 	"github.com/dmitrykharchenko95/fibonacci/internal/server/grpc/pb"
+	fibonacciv1 "github.com/dmitrykharchenko95/fibonacci/internal/server/grpc/pb/fibonacci/v1"
 	"github.com/dmitrykharchenko95/fibonacci/internal/service"
+	"github.com/dmitrykharchenko95/fibonacci/internal/tlsconfig"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
 type Server struct {
-	srv *grpc.Server
-	rdb *rds.Client
-	addr string
-	timeout time.Duration
+	srv     *grpc.Server
+	rdb     *rds.Client
+	runner  *compute.Runner
+	guard   *auth.Guard
+	addrs   []string
+	timeout atomic.Int64
+	maxPage atomic.Int64
 	pb.UnimplementedFibonacciServer
 }
 
-func New(host, port string, timeout time.Duration, rdb *rds.Client)  *Server{
-	return &Server{
-		srv: grpc.NewServer(),
-		rdb: rdb,
-		addr: net.JoinHostPort(host, port),
-		timeout: timeout,
+// New создает новый объект типа Server, который будет прослушивать адреса addrs (форматы адресов описаны в
+// listener.Parse).
+func New(addrs []string, timeout time.Duration, rdb *rds.Client, sch *scheduler.Scheduler, guard *auth.Guard,
+	opts ...grpc.ServerOption) *Server {
+	s := &Server{
+		srv:    grpc.NewServer(opts...),
+		rdb:    rdb,
+		runner: compute.New(sch, guard),
+		guard:  guard,
+		addrs:  addrs,
 	}
+	s.SetTimeout(timeout)
+	s.SetMaxPageSize(pagination.DefaultMaxSize)
+
+	pb.RegisterFibonacciServer(s.srv, s)
+	fibonacciv1.RegisterFibonacciServiceServer(s.srv, &serverV1{s: s})
+	return s
+}
+
+// Timeout возвращает максимальное время вычисления чисел Фибоначчи в рамках одного вызова.
+func (s *Server) Timeout() time.Duration {
+	return time.Duration(s.timeout.Load())
+}
+
+// SetTimeout изменяет максимальное время вычисления. Может вызываться во время работы сервера.
+func (s *Server) SetTimeout(timeout time.Duration) {
+	s.timeout.Store(int64(timeout))
+}
+
+// MaxPageSize возвращает наибольшее количество чисел в одной странице диапазона.
+func (s *Server) MaxPageSize() int {
+	return int(s.maxPage.Load())
+}
+
+// SetMaxPageSize изменяет наибольший размер страницы диапазона. Может вызываться во время работы сервера.
+func (s *Server) SetMaxPageSize(size int) {
+	s.maxPage.Store(int64(size))
 }
 
-func (s *Server) Start () error {
-	lsn, err := net.Listen("tcp", s.addr)
+// Start запускает grpc сервер на всех адресах. Если какой-либо адрес не удалось прослушивать, сервер не запускается.
+func (s *Server) Start() error {
+	lsns, err := listener.ListenAll(s.addrs)
 	if err != nil {
 		return err
 	}
-	pb.RegisterFibonacciServer(s.srv, s)
-	log.Printf("Start grpc server on %s...\n", s.addr)
-	if err := s.srv.Serve(lsn); err != nil {
-		return err
-	}
-	return nil
+
+	return listener.ServeAll(lsns, func(l net.Listener) error {
+		slog.Info("start grpc server", "network", l.Addr().Network(), "addr", l.Addr().String())
+		if err := s.srv.Serve(l); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			return err
+		}
+		return nil
+	})
 }
 
-func (s *Server) Stop () {
-	log.Println("Stop grpc server...")
-	s.srv.Stop()
+// Stop прекращает прием новых вызовов и дожидается завершения выполняемых. После отмены ctx оставшиеся вызовы
+// прерываются.
+func (s *Server) Stop(ctx context.Context) {
+	slog.Info("stop grpc server")
+
+	done := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("grpc server grace period expired, closing connections")
+		s.srv.Stop()
+		<-done
+	}
 }
 
+// GetFibonacci реализует метод getFibonacci устаревшего сервиса pb.fibonacci: ошибка истечения времени вычисления
+// передается в поле err ответа вместе с числами, вычисленными до его истечения. Диапазон может содержать не больше
+// MaxPageSize чисел.
+//
+// Deprecated: следует использовать сервис fibonacci.v1.FibonacciService. Ответы содержат метаданные deprecation.
 func (s *Server) GetFibonacci(ctx context.Context, req *pb.Request) (*pb.Response, error) {
+	if err := grpc.SetHeader(ctx, metadata.Pairs("deprecation", "true")); err != nil {
+		slog.ErrorContext(ctx, "can not set deprecation header", "error", err)
+	}
+
 	var x, y int64
 	if req.X > req.Y {
 		x, y = req.Y, req.X
@@ -57,27 +130,121 @@ func (s *Server) GetFibonacci(ctx context.Context, req *pb.Request) (*pb.Respons
 		x, y = req.X, req.Y
 	}
 
-	ip, err := getClientIP(ctx)
-	if err != nil {
-		log.Printf("can not get client IP: %v", err)
+	if n, maxSize := (service.Range{From: int(x), To: int(y)}).Len(), s.MaxPageSize(); n > uint64(maxSize) {
+		return nil, status.Errorf(codes.InvalidArgument, "request should contain at most %d numbers, got %d", maxSize, n)
 	}
 
-	resp := &pb.Response{}
-	data, err := service.GetFibonacci(int(x), int(y), s.timeout,s.rdb)
+	its, err := s.compute(ctx, int(x), int(y))
+	switch {
+	case errors.Is(err, scheduler.ErrQueueFull), errors.Is(err, scheduler.ErrQueueTimeout):
+		return nil, statusV1(err)
+	case errors.Is(err, service.ErrTimeoutExit):
+	case err != nil:
+		return nil, err
+	}
+
+	resp := &pb.Response{Data: service.Values(its)}
 	if err != nil {
-		resp.Data, resp.Err = data, err.Error()
-	} else {
-		resp.Data = data
+		resp.Err = err.Error()
 	}
 
-	log.Printf("%v: sended %v numbers fibonacci\n", ip, len(resp.Data))
+	slog.InfoContext(ctx, "sent fibonacci numbers", "client", getClientName(ctx), "count", len(resp.Data))
 	return resp, nil
 }
 
+// run выполняет вычисление fn стоимостью cost для порядковых номеров от lo до hi через compute.Runner. Ошибки
+// проверки прав возвращаются gRPC статусами, ошибки планировщика и fn - как есть.
+func (s *Server) run(ctx context.Context, lo, hi int, cost int64, fn func(timeout time.Duration) error) error {
+	err := s.runner.Run(ctx, getClientName(ctx), lo, hi, cost, s.Timeout(), fn)
+	if errors.Is(err, auth.ErrForbidden) {
+		return authStatus(err)
+	}
+	return err
+}
+
+// compute вычисляет числа Фибоначчи с порядковыми номерами от x до y включительно: проверяет права клиента на диапазон,
+// дожидается свободного места в планировщике и учитывает время вычисления в квоте клиента. Ошибки авторизации
+// возвращаются gRPC статусами, ошибки планировщика и истечения времени вычисления - как есть. При истечении времени
+// возвращаются также числа, вычисленные до его истечения.
+func (s *Server) compute(ctx context.Context, x, y int) ([]service.Item, error) {
+	var its []service.Item
+	err := s.run(ctx, x, y, scheduler.Cost(x, y), func(timeout time.Duration) (err error) {
+		its, err = service.GetFibonacciItems(ctx, x, y, timeout, s.rdb)
+		return err
+	})
+	return its, err
+}
+
+// computeIndices вычисляет числа Фибоначчи с порядковыми номерами indices аналогично compute.
+func (s *Server) computeIndices(ctx context.Context, indices []int) ([]service.Item, error) {
+	var (
+		cost   int64
+		lo, hi = indices[0], indices[0]
+	)
+	for _, i := range indices {
+		cost = scheduler.AddCost(cost, scheduler.Cost(i, i))
+		lo, hi = min(lo, i), max(hi, i)
+	}
+
+	var its []service.Item
+	err := s.run(ctx, lo, hi, cost, func(timeout time.Duration) (err error) {
+		its, err = service.GetIndicesItems(ctx, indices, timeout, s.rdb)
+		return err
+	})
+	return its, err
+}
+
+// computeBatch вычисляет числа Фибоначчи для диапазонов ranges в пределах общего времени вычисления: дожидается
+// свободного места в планировщике со стоимостью объединенных диапазонов и учитывает время вычисления в квоте клиента.
+// Права клиента на каждый диапазон проверяются вызывающим.
+func (s *Server) computeBatch(ctx context.Context, ranges []service.Range) ([]service.BatchResult, error) {
+	if len(ranges) == 0 {
+		return nil, nil
+	}
+
+	var (
+		cost   int64
+		merged = service.Merge(ranges)
+	)
+	for _, m := range merged {
+		cost = scheduler.AddCost(cost, scheduler.Cost(m.From, m.To))
+	}
+
+	var results []service.BatchResult
+	err := s.run(ctx, merged[0].From, merged[len(merged)-1].To, cost, func(timeout time.Duration) error {
+		results = service.GetBatch(ctx, ranges, timeout, s.rdb)
+		return nil
+	})
+	return results, err
+}
+
 func getClientIP(ctx context.Context) (string, error) {
 	p, ok := peer.FromContext(ctx)
 	if !ok {
 		return "", fmt.Errorf("couldn't parse client IP address")
 	}
 	return p.Addr.String(), nil
-}
\ No newline at end of file
+}
+
+// getClientName возвращает адрес клиента для логов, дополненный именем из клиентского сертификата при mTLS.
+func getClientName(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "unknown"
+	}
+
+	if name := getPeerName(p); name != "" {
+		return fmt.Sprintf("%v (%v)", p.Addr, name)
+	}
+
+	return p.Addr.String()
+}
+
+// getPeerName возвращает имя клиента из проверенного клиентского сертификата.
+func getPeerName(p *peer.Peer) string {
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ""
+	}
+	return tlsconfig.PeerName(&info.State)
+}
@@ -0,0 +1,280 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/pagination"
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
+	fibonacciv1 "github.com/dmitrykharchenko95/fibonacci/internal/server/grpc/pb/fibonacci/v1"
+	"github.com/dmitrykharchenko95/fibonacci/internal/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// retryDelay - время, через которое клиенту предлагается повторить вызов, отклоненный из-за перегрузки сервера.
+const retryDelay = time.Second
+
+// serverV1 реализует сервис fibonacci.v1.FibonacciService. Ошибки передаются gRPC статусами с подробностями
+// google.rpc (errdetails).
+type serverV1 struct {
+	s *Server
+	fibonacciv1.UnimplementedFibonacciServiceServer
+}
+
+func (v *serverV1) GetNumber(ctx context.Context, req *fibonacciv1.GetNumberRequest) (*fibonacciv1.GetNumberResponse,
+	error) {
+	start := time.Now()
+	if err := checkEncoding(req.Encoding); err != nil {
+		return nil, err
+	}
+
+	its, err := v.s.compute(ctx, int(req.N), int(req.N))
+	if err != nil {
+		return nil, statusV1(err)
+	}
+
+	slog.InfoContext(ctx, "sent fibonacci numbers", "client", getClientName(ctx), "count", len(its))
+	return &fibonacciv1.GetNumberResponse{
+		Item:  items(its, req.Encoding, req.Verbose)[0],
+		Stats: stats(its, start, req.Verbose),
+	}, nil
+}
+
+func (v *serverV1) GetRange(ctx context.Context, req *fibonacciv1.GetRangeRequest) (*fibonacciv1.GetRangeResponse,
+	error) {
+	start := time.Now()
+	if req.From > req.To {
+		return nil, badRequest("to", "must not be less than from")
+	}
+	if req.PageSize < 0 {
+		return nil, badRequest("page_size", "must not be negative")
+	}
+	if err := checkEncoding(req.Encoding); err != nil {
+		return nil, err
+	}
+
+	page, err := pagination.Paginate(int(req.From), int(req.To), pagination.Size(int(req.PageSize), v.s.MaxPageSize()),
+		req.PageToken)
+	if err != nil {
+		return nil, badRequest("page_token", err.Error())
+	}
+
+	its, err := v.s.compute(ctx, page.From, page.To)
+	if err != nil && (len(its) == 0 || !errors.Is(err, service.ErrTimeoutExit)) {
+		return nil, statusV1(err)
+	}
+
+	slog.InfoContext(ctx, "sent fibonacci numbers", "client", getClientName(ctx), "count", len(its))
+	return &fibonacciv1.GetRangeResponse{
+		Items:         items(its, req.Encoding, req.Verbose),
+		Partial:       err != nil,
+		Expected:      int64(page.Len()),
+		NextPageToken: page.Next(len(its)),
+		Stats:         stats(its, start, req.Verbose),
+	}, nil
+}
+
+func (v *serverV1) GetIndices(ctx context.Context, req *fibonacciv1.GetIndicesRequest) (
+	*fibonacciv1.GetIndicesResponse, error) {
+	start := time.Now()
+	indices, err := indexSet(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEncoding(req.Encoding); err != nil {
+		return nil, err
+	}
+
+	its, err := v.s.computeIndices(ctx, indices)
+	if err != nil && (len(its) == 0 || !errors.Is(err, service.ErrTimeoutExit)) {
+		return nil, statusV1(err)
+	}
+
+	slog.InfoContext(ctx, "sent fibonacci numbers", "client", getClientName(ctx), "count", len(its))
+	return &fibonacciv1.GetIndicesResponse{
+		Items:    items(its, req.Encoding, req.Verbose),
+		Partial:  err != nil,
+		Expected: int64(len(indices)),
+		Stats:    stats(its, start, req.Verbose),
+	}, nil
+}
+
+// indexSet возвращает порядковые номера из списка или арифметической прогрессии запроса req.
+func indexSet(req *fibonacciv1.GetIndicesRequest) ([]int, error) {
+	switch set := req.Set.(type) {
+	case *fibonacciv1.GetIndicesRequest_List:
+		n := len(set.List.GetIndices())
+		if n == 0 || n > service.MaxIndices {
+			return nil, badRequest("list.indices", fmt.Sprintf("must contain from 1 to %d indices", service.MaxIndices))
+		}
+
+		indices := make([]int, n)
+		for i, idx := range set.List.Indices {
+			indices[i] = int(idx)
+		}
+		return indices, nil
+	case *fibonacciv1.GetIndicesRequest_Progression:
+		p := set.Progression
+		indices, ok := service.Progression(int(p.GetFrom()), int(p.GetTo()), int(p.GetStep()))
+		if !ok {
+			return nil, badRequest("progression.step",
+				fmt.Sprintf("must be non-zero, lead from from to to and give at most %d indices", service.MaxIndices))
+		}
+		return indices, nil
+	}
+
+	return nil, badRequest("set", "either list or progression must be set")
+}
+
+func (v *serverV1) GetBatch(ctx context.Context, req *fibonacciv1.GetBatchRequest) (*fibonacciv1.GetBatchResponse,
+	error) {
+	start := time.Now()
+	if len(req.Queries) == 0 || len(req.Queries) > service.MaxBatch {
+		return nil, badRequest("queries", fmt.Sprintf("number of queries must be from 1 to %d", service.MaxBatch))
+	}
+	if err := checkEncoding(req.Encoding); err != nil {
+		return nil, err
+	}
+
+	var (
+		resp    = &fibonacciv1.GetBatchResponse{Results: make([]*fibonacciv1.BatchResult, len(req.Queries))}
+		ranges  = make([]service.Range, 0, len(req.Queries))
+		queries = make([]int, 0, len(req.Queries))
+	)
+	for i, q := range req.Queries {
+		res := &fibonacciv1.BatchResult{Id: q.Id}
+		resp.Results[i] = res
+
+		from, to, err := v.resolve(ctx, i, q)
+		if err != nil {
+			res.Error = status.Convert(err).Proto()
+			slog.WarnContext(ctx, "batch query rejected", "client", getClientName(ctx), "query", i, "error", err)
+			continue
+		}
+
+		rng := service.Range{From: int(from), To: int(to)}
+		res.Expected = int64(rng.Len())
+		ranges = append(ranges, rng)
+		queries = append(queries, i)
+	}
+
+	if maxSize := v.s.MaxPageSize(); service.Total(ranges) > uint64(maxSize) {
+		return nil, badRequest("queries", fmt.Sprintf("must request at most %d numbers in total", maxSize))
+	}
+
+	results, err := v.s.computeBatch(ctx, ranges)
+	if err != nil {
+		return nil, statusV1(err)
+	}
+
+	var its []service.Item
+	for k, r := range results {
+		res := resp.Results[queries[k]]
+		res.Items = items(r.Items, req.Encoding, req.Verbose)
+
+		switch {
+		case r.Err != nil && len(r.Values) > 0:
+			res.Partial = true
+		case r.Err != nil:
+			res.Error = status.Convert(statusV1(r.Err)).Proto()
+		}
+		its = append(its, r.Items...)
+	}
+	resp.Stats = stats(its, start, req.Verbose)
+
+	slog.InfoContext(ctx, "sent fibonacci numbers", "client", getClientName(ctx), "count", len(its),
+		"queries", len(req.Queries))
+	return resp, nil
+}
+
+// resolve возвращает диапазон порядковых номеров подзапроса q с номером i в пакете и проверяет права клиента на него.
+// Диапазон может содержать не больше MaxPageSize чисел.
+func (v *serverV1) resolve(ctx context.Context, i int, q *fibonacciv1.BatchQuery) (int64, int64, error) {
+	field := fmt.Sprintf("queries[%d]", i)
+
+	var from, to int64
+	switch q := q.Query.(type) {
+	case *fibonacciv1.BatchQuery_N:
+		from, to = q.N, q.N
+	case *fibonacciv1.BatchQuery_Range:
+		if q.Range.GetFrom() > q.Range.GetTo() {
+			return 0, 0, badRequest(field+".range.to", "must not be less than from")
+		}
+		from, to = q.Range.GetFrom(), q.Range.GetTo()
+	default:
+		return 0, 0, badRequest(field, "either n or range must be set")
+	}
+
+	if maxSize := v.s.MaxPageSize(); (service.Range{From: int(from), To: int(to)}).Len() > uint64(maxSize) {
+		return 0, 0, badRequest(field+".range.to", fmt.Sprintf("range must contain at most %d numbers", maxSize))
+	}
+
+	if err := v.s.guard.CheckRange(ctx, int(from), int(to)); err != nil {
+		return 0, 0, authStatus(err)
+	}
+
+	return from, to, nil
+}
+
+// item возвращает число Фибоначчи с порядковым номером index и десятичным значением val в кодировке enc.
+func item(index int64, val string, enc fibonacciv1.Encoding) *fibonacciv1.Item {
+	it := &fibonacciv1.Item{Index: index}
+	if enc != fibonacciv1.Encoding_ENCODING_BYTES {
+		it.Decimal = val
+		return it
+	}
+
+	n, _ := new(big.Int).SetString(val, 10)
+	it.Magnitude, it.Negative = n.Bytes(), n.Sign() < 0
+	return it
+}
+
+func checkEncoding(enc fibonacciv1.Encoding) error {
+	if _, ok := fibonacciv1.Encoding_name[int32(enc)]; !ok {
+		return badRequest("encoding", "unknown encoding")
+	}
+	return nil
+}
+
+// badRequest возвращает статус INVALID_ARGUMENT с подробностями google.rpc.BadRequest о неверном поле field.
+func badRequest(field, desc string) error {
+	return withDetails(status.New(codes.InvalidArgument, field+": "+desc),
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: field, Description: desc},
+		}})
+}
+
+// statusV1 преобразует ошибку вычисления err в gRPC статус. Ошибки, уже являющиеся статусами, возвращаются как есть.
+func statusV1(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, scheduler.ErrQueueFull), errors.Is(err, scheduler.ErrQueueTimeout):
+		return withDetails(status.New(codes.ResourceExhausted, err.Error()),
+			&errdetails.RetryInfo{RetryDelay: durationpb.New(retryDelay)})
+	case errors.Is(err, service.ErrTimeoutExit):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// withDetails добавляет к статусу st подробности details. Если подробности не удалось добавить, статус возвращается
+// без них.
+func withDetails(st *status.Status, details ...protoiface.MessageV1) error {
+	ds, err := st.WithDetails(details...)
+	if err != nil {
+		slog.Error("can not add status details", "error", err)
+		return st.Err()
+	}
+	return ds.Err()
+}
@@ -0,0 +1,255 @@
+package grpcserver
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/pagination"
+	"github.com/dmitrykharchenko95/fibonacci/internal/rds"
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
+	"github.com/dmitrykharchenko95/fibonacci/internal/server/grpc/pb"
+	fibonacciv1 "github.com/dmitrykharchenko95/fibonacci/internal/server/grpc/pb/fibonacci/v1"
+	"github.com/dmitrykharchenko95/fibonacci/internal/service"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestServerV1(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "grpc.sock")
+	rdb := rds.NewClient(redis.NewClient(&redis.Options{}), time.Hour, 0)
+	s := New([]string{"unix://" + sock}, 200*time.Millisecond, rdb, scheduler.New(1, 10), nil)
+
+	go func() {
+		require.NoError(t, s.Start())
+	}()
+	defer s.Stop(context.Background())
+
+	conn, err := grpc.Dial("unix://"+sock, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx := context.Background()
+	cl := fibonacciv1.NewFibonacciServiceClient(conn)
+
+	t.Run("number", func(t *testing.T) {
+		resp, err := cl.GetNumber(ctx, &fibonacciv1.GetNumberRequest{N: 10}, grpc.WaitForReady(true))
+		require.NoError(t, err)
+		require.Equal(t, int64(10), resp.Item.Index)
+		require.Equal(t, "55", resp.Item.Decimal)
+	})
+
+	t.Run("range bytes", func(t *testing.T) {
+		resp, err := cl.GetRange(ctx, &fibonacciv1.GetRangeRequest{From: -2, To: 100,
+			Encoding: fibonacciv1.Encoding_ENCODING_BYTES})
+		require.NoError(t, err)
+		require.False(t, resp.Partial)
+		require.Equal(t, int64(103), resp.Expected)
+		require.Len(t, resp.Items, 103)
+
+		require.Equal(t, int64(-2), resp.Items[0].Index)
+		require.True(t, resp.Items[0].Negative)
+		require.Equal(t, []byte{1}, resp.Items[0].Magnitude)
+		require.Empty(t, resp.Items[2].Magnitude)
+
+		f100, _ := new(big.Int).SetString("354224848179261915075", 10)
+		require.Equal(t, f100.Bytes(), resp.Items[102].Magnitude)
+		require.Empty(t, resp.Items[102].Decimal)
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		resp, err := cl.GetRange(ctx, &fibonacciv1.GetRangeRequest{From: 0, To: 1000000})
+		require.NoError(t, err)
+		require.True(t, resp.Partial)
+		require.NotEmpty(t, resp.Items)
+		require.Less(t, len(resp.Items), int(resp.Expected))
+		require.NotEmpty(t, resp.NextPageToken)
+
+		_, err = cl.GetNumber(ctx, &fibonacciv1.GetNumberRequest{N: 100000000})
+		require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	})
+
+	t.Run("pages", func(t *testing.T) {
+		req := &fibonacciv1.GetRangeRequest{From: 0, To: 6, PageSize: 3}
+
+		var values []string
+		for {
+			resp, err := cl.GetRange(ctx, req)
+			require.NoError(t, err)
+			for _, it := range resp.Items {
+				values = append(values, strconv.FormatInt(it.Index, 10)+":"+it.Decimal)
+			}
+
+			if resp.NextPageToken == "" {
+				break
+			}
+			req.PageToken = resp.NextPageToken
+		}
+		require.Equal(t, []string{"0:0", "1:1", "2:1", "3:2", "4:3", "5:5", "6:8"}, values)
+
+		_, err := cl.GetRange(ctx, &fibonacciv1.GetRangeRequest{From: 1, To: 6, PageToken: req.PageToken})
+		st := status.Convert(err)
+		require.Equal(t, codes.InvalidArgument, st.Code())
+		require.Equal(t, "page_token", st.Details()[0].(*errdetails.BadRequest).FieldViolations[0].Field)
+	})
+
+	t.Run("verbose", func(t *testing.T) {
+		resp, err := cl.GetRange(ctx, &fibonacciv1.GetRangeRequest{From: 10, To: 12, Verbose: true})
+		require.NoError(t, err)
+		require.Equal(t, int64(3), resp.Stats.Count)
+		require.Zero(t, resp.Stats.CacheHits)
+		require.NotNil(t, resp.Stats.Elapsed)
+
+		it := resp.Items[2]
+		require.Equal(t, "144", it.Decimal)
+		require.Equal(t, int32(3), it.Digits)
+		require.Equal(t, fibonacciv1.Source_SOURCE_COMPUTED, it.Source)
+		require.NotNil(t, it.ComputeTime)
+
+		num, err := cl.GetNumber(ctx, &fibonacciv1.GetNumberRequest{N: 12})
+		require.NoError(t, err)
+		require.Nil(t, num.Stats)
+		require.Equal(t, fibonacciv1.Source_SOURCE_UNSPECIFIED, num.Item.Source)
+		require.Nil(t, num.Item.ComputeTime)
+	})
+
+	t.Run("invalid argument", func(t *testing.T) {
+		_, err := cl.GetRange(ctx, &fibonacciv1.GetRangeRequest{From: 5, To: 1})
+		st := status.Convert(err)
+		require.Equal(t, codes.InvalidArgument, st.Code())
+		require.Len(t, st.Details(), 1)
+
+		br, ok := st.Details()[0].(*errdetails.BadRequest)
+		require.True(t, ok)
+		require.Equal(t, "to", br.FieldViolations[0].Field)
+
+		_, err = cl.GetNumber(ctx, &fibonacciv1.GetNumberRequest{N: 1, Encoding: 42})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("indices", func(t *testing.T) {
+		resp, err := cl.GetIndices(ctx, &fibonacciv1.GetIndicesRequest{Set: &fibonacciv1.GetIndicesRequest_Progression{
+			Progression: &fibonacciv1.Progression{From: 25, To: 5, Step: -10},
+		}})
+		require.NoError(t, err)
+		require.Equal(t, int64(3), resp.Expected)
+
+		var values []string
+		for _, it := range resp.Items {
+			values = append(values, strconv.FormatInt(it.Index, 10)+":"+it.Decimal)
+		}
+		require.Equal(t, []string{"25:75025", "15:610", "5:5"}, values)
+
+		resp, err = cl.GetIndices(ctx, &fibonacciv1.GetIndicesRequest{
+			Set: &fibonacciv1.GetIndicesRequest_List{List: &fibonacciv1.IndexList{Indices: []int64{100, -6}}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "354224848179261915075", resp.Items[0].Decimal)
+		require.Equal(t, "-8", resp.Items[1].Decimal)
+
+		_, err = cl.GetIndices(ctx, &fibonacciv1.GetIndicesRequest{
+			Set: &fibonacciv1.GetIndicesRequest_Progression{Progression: &fibonacciv1.Progression{From: 0, To: 5}},
+		})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = cl.GetIndices(ctx, &fibonacciv1.GetIndicesRequest{})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		resp, err := cl.GetBatch(ctx, &fibonacciv1.GetBatchRequest{Queries: []*fibonacciv1.BatchQuery{
+			{Id: "a", Query: &fibonacciv1.BatchQuery_N{N: 10}},
+			{Query: &fibonacciv1.BatchQuery_Range{Range: &fibonacciv1.Range{From: 8, To: 11}}},
+			{Query: &fibonacciv1.BatchQuery_Range{Range: &fibonacciv1.Range{From: 3, To: 1}}},
+			{},
+			{Query: &fibonacciv1.BatchQuery_N{N: 100000000}},
+		}})
+		require.NoError(t, err)
+		require.Len(t, resp.Results, 5)
+
+		require.Equal(t, "a", resp.Results[0].Id)
+		require.Equal(t, "55", resp.Results[0].Items[0].Decimal)
+
+		var values []string
+		for _, it := range resp.Results[1].Items {
+			values = append(values, it.Decimal)
+		}
+		require.Equal(t, []string{"21", "34", "55", "89"}, values)
+		require.Equal(t, int64(11), resp.Results[1].Items[3].Index)
+		require.Nil(t, resp.Results[1].Error)
+
+		require.Equal(t, int32(codes.InvalidArgument), resp.Results[2].Error.Code)
+		require.Equal(t, int32(codes.InvalidArgument), resp.Results[3].Error.Code)
+		require.Equal(t, int32(codes.DeadlineExceeded), resp.Results[4].Error.Code)
+		require.Nil(t, resp.Stats)
+		require.Equal(t, fibonacciv1.Source_SOURCE_UNSPECIFIED, resp.Results[0].Items[0].Source)
+
+		resp, err = cl.GetBatch(ctx, &fibonacciv1.GetBatchRequest{Verbose: true, Queries: []*fibonacciv1.BatchQuery{
+			{Query: &fibonacciv1.BatchQuery_N{N: 12}},
+			{Query: &fibonacciv1.BatchQuery_Range{Range: &fibonacciv1.Range{From: 8, To: 9}}},
+		}})
+		require.NoError(t, err)
+		require.Equal(t, int64(3), resp.Stats.Count)
+		require.NotNil(t, resp.Stats.Elapsed)
+
+		it := resp.Results[0].Items[0]
+		require.Equal(t, "144", it.Decimal)
+		require.Equal(t, int32(3), it.Digits)
+		require.Equal(t, fibonacciv1.Source_SOURCE_COMPUTED, it.Source)
+		require.NotNil(t, it.ComputeTime)
+		require.Equal(t, int64(9), resp.Results[1].Items[1].Index)
+
+		_, err = cl.GetBatch(ctx, &fibonacciv1.GetBatchRequest{})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		resp, err = cl.GetBatch(ctx, &fibonacciv1.GetBatchRequest{Queries: []*fibonacciv1.BatchQuery{
+			{Query: &fibonacciv1.BatchQuery_Range{Range: &fibonacciv1.Range{From: 0, To: 1e13}}},
+			{Query: &fibonacciv1.BatchQuery_Range{Range: &fibonacciv1.Range{From: math.MinInt64, To: math.MaxInt64}}},
+		}})
+		require.NoError(t, err)
+		require.Equal(t, int32(codes.InvalidArgument), resp.Results[0].Error.Code)
+		require.Equal(t, int32(codes.InvalidArgument), resp.Results[1].Error.Code)
+
+		queries := make([]*fibonacciv1.BatchQuery, 2)
+		for i := range queries {
+			from := int64(i) * pagination.DefaultMaxSize
+			queries[i] = &fibonacciv1.BatchQuery{Query: &fibonacciv1.BatchQuery_Range{
+				Range: &fibonacciv1.Range{From: from, To: from + pagination.DefaultMaxSize/2},
+			}}
+		}
+		_, err = cl.GetBatch(ctx, &fibonacciv1.GetBatchRequest{Queries: queries})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("legacy", func(t *testing.T) {
+		var md metadata.MD
+		resp, err := pb.NewFibonacciClient(conn).GetFibonacci(ctx, &pb.Request{X: 6, Y: 0}, grpc.Header(&md))
+		require.NoError(t, err)
+		require.Equal(t, []string{"0", "1", "1", "2", "3", "5", "8"}, resp.Data)
+		require.Equal(t, []string{"true"}, md.Get("deprecation"))
+
+		_, err = pb.NewFibonacciClient(conn).GetFibonacci(ctx, &pb.Request{X: math.MinInt64, Y: math.MaxInt64})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestStatusV1(t *testing.T) {
+	for _, err := range []error{scheduler.ErrQueueFull, scheduler.ErrQueueTimeout} {
+		st := status.Convert(statusV1(err))
+		require.Equal(t, codes.ResourceExhausted, st.Code())
+		require.Len(t, st.Details(), 1)
+		require.IsType(t, &errdetails.RetryInfo{}, st.Details()[0])
+	}
+
+	require.Equal(t, codes.DeadlineExceeded, status.Code(statusV1(service.ErrTimeoutExit)))
+}
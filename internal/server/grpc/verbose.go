@@ -0,0 +1,44 @@
+package grpcserver
+
+import (
+	"time"
+
+	fibonacciv1 "github.com/dmitrykharchenko95/fibonacci/internal/server/grpc/pb/fibonacci/v1"
+	"github.com/dmitrykharchenko95/fibonacci/internal/service"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// sources - значения fibonacciv1.Source для источников значений чисел.
+var sources = map[service.Source]fibonacciv1.Source{
+	service.SourceComputed: fibonacciv1.Source_SOURCE_COMPUTED,
+	service.SourceRedis:    fibonacciv1.Source_SOURCE_REDIS,
+}
+
+// items возвращает числа its в кодировке enc. При verbose у чисел заполняются количество цифр, источник значения и
+// время его получения.
+func items(its []service.Item, enc fibonacciv1.Encoding, verbose bool) []*fibonacciv1.Item {
+	res := make([]*fibonacciv1.Item, len(its))
+	for i, it := range its {
+		res[i] = item(int64(it.Index), it.Value, enc)
+		if verbose {
+			res[i].Digits = int32(it.Digits())
+			res[i].Source = sources[it.Source]
+			res[i].ComputeTime = durationpb.New(it.Duration)
+		}
+	}
+	return res
+}
+
+// stats возвращает итоги вызова с числами its, обработка которого началась в start, или nil, если вызов выполнен без
+// verbose.
+func stats(its []service.Item, start time.Time, verbose bool) *fibonacciv1.Stats {
+	if !verbose {
+		return nil
+	}
+
+	return &fibonacciv1.Stats{
+		Count:     int64(len(its)),
+		CacheHits: int64(service.CacheHits(its)),
+		Elapsed:   durationpb.New(time.Since(start)),
+	}
+}
@@ -0,0 +1,41 @@
+package grpcserver
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"google.golang.org/grpc/reflection"
+)
+
+// RegisterReflection регистрирует на сервере сервис grpc.reflection, позволяющий клиентам (например, grpcurl)
+// получать описания сервисов без proto-файлов. Должен вызываться до Start.
+func (s *Server) RegisterReflection() {
+	reflection.Register(s.srv)
+}
+
+// GRPCWeb возвращает обработчик запросов gRPC-Web и предварительных CORS запросов к сервисам сервера. Вызовы
+// выполняются gRPC сервером, поэтому к ним применяются его интерсепторы. origins - источники, из которых разрешены
+// кросс-доменные запросы; "*" разрешает любой источник.
+func (s *Server) GRPCWeb(origins []string) http.Handler {
+	allowed := make(map[string]bool, len(origins))
+	for _, o := range origins {
+		allowed[o] = true
+	}
+
+	return grpcweb.WrapServer(s.srv, grpcweb.WithOriginFunc(func(origin string) bool {
+		return allowed["*"] || allowed[origin]
+	}))
+}
+
+// Paths возвращает шаблоны путей HTTP запросов к сервисам, зарегистрированным на сервере, в формате "/<сервис>/".
+func (s *Server) Paths() []string {
+	info := s.srv.GetServiceInfo()
+	paths := make([]string, 0, len(info))
+	for name := range info {
+		paths = append(paths, "/"+name+"/")
+	}
+
+	sort.Strings(paths)
+	return paths
+}
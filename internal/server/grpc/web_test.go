@@ -0,0 +1,118 @@
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/rds"
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
+	fibonacciv1 "github.com/dmitrykharchenko95/fibonacci/internal/server/grpc/pb/fibonacci/v1"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestReflection(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "grpc.sock")
+	rdb := rds.NewClient(redis.NewClient(&redis.Options{}), time.Hour, 0)
+	s := New([]string{"unix://" + sock}, time.Second, rdb, scheduler.New(1, 10), nil)
+	s.RegisterReflection()
+
+	go func() {
+		require.NoError(t, s.Start())
+	}()
+	defer s.Stop(context.Background())
+
+	conn, err := grpc.Dial("unix://"+sock, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background(), grpc.WaitForReady(true))
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{},
+	}))
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+
+	var names []string
+	for _, svc := range resp.GetListServicesResponse().Service {
+		names = append(names, svc.Name)
+	}
+	require.Contains(t, names, "fibonacci.v1.FibonacciService")
+	require.Contains(t, names, "pb.fibonacci")
+}
+
+func TestGRPCWeb(t *testing.T) {
+	rdb := rds.NewClient(redis.NewClient(&redis.Options{}), time.Hour, 0)
+	s := New(nil, time.Second, rdb, scheduler.New(1, 10), nil)
+	require.Equal(t, []string{"/fibonacci.v1.FibonacciService/", "/pb.fibonacci/"}, s.Paths())
+
+	srv := httptest.NewServer(s.GRPCWeb([]string{"https://example.com"}))
+	defer srv.Close()
+
+	t.Run("call", func(t *testing.T) {
+		msg, err := proto.Marshal(&fibonacciv1.GetNumberRequest{N: 10})
+		require.NoError(t, err)
+
+		resp, err := http.Post(srv.URL+"/fibonacci.v1.FibonacciService/GetNumber", "application/grpc-web+proto",
+			bytes.NewReader(frame(0, msg)))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		// Ответ gRPC-Web состоит из кадра с сообщением и кадра с трейлерами.
+		require.Equal(t, byte(0), body[0])
+		n := binary.BigEndian.Uint32(body[1:5])
+		var out fibonacciv1.GetNumberResponse
+		require.NoError(t, proto.Unmarshal(body[5:5+n], &out))
+		require.Equal(t, "55", out.Item.Decimal)
+
+		trailer := body[5+n:]
+		require.Equal(t, byte(0x80), trailer[0])
+		require.Contains(t, strings.ToLower(string(trailer[5:])), "grpc-status: 0")
+	})
+
+	t.Run("cors", func(t *testing.T) {
+		for origin, allowed := range map[string]bool{"https://example.com": true, "https://other.com": false} {
+			req, err := http.NewRequest(http.MethodOptions, srv.URL+"/fibonacci.v1.FibonacciService/GetNumber", nil)
+			require.NoError(t, err)
+			req.Header.Set("Origin", origin)
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			req.Header.Set("Access-Control-Request-Headers", "x-grpc-web,content-type")
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			if allowed {
+				require.Equal(t, origin, resp.Header.Get("Access-Control-Allow-Origin"))
+			} else {
+				require.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+			}
+		}
+	})
+}
+
+// frame возвращает кадр gRPC-Web с флагами flags и данными data.
+func frame(flags byte, data []byte) []byte {
+	b := make([]byte, 5, 5+len(data))
+	b[0] = flags
+	binary.BigEndian.PutUint32(b[1:], uint32(len(data)))
+	return append(b, data...)
+}
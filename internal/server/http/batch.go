@@ -0,0 +1,265 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
+	"github.com/dmitrykharchenko95/fibonacci/internal/server/grpc/pb"
+	"github.com/dmitrykharchenko95/fibonacci/internal/service"
+)
+
+// batchPath - путь эндпоинта пакетных запросов.
+const batchPath = fibonacciPath + "/batch"
+
+// maxBatchBody - наибольший размер тела пакетного запроса в байтах.
+const maxBatchBody = 1 << 20
+
+// BatchRequest - тело запроса POST /v1/fibonacci/batch: от 1 до service.MaxBatch подзапросов. Verbose запрашивает
+// подробный ответ, как параметр verbose=true.
+type BatchRequest struct {
+	Queries []BatchQuery `json:"queries"`
+	Verbose bool         `json:"verbose,omitempty"`
+}
+
+// BatchQuery - подзапрос пакета: число с порядковым номером N или числа с порядковыми номерами от From до To
+// включительно. ID - необязательный ключ подзапроса, возвращаемый в BatchResult.
+type BatchQuery struct {
+	ID   string `json:"id,omitempty"`
+	N    *int   `json:"n,omitempty"`
+	From *int   `json:"from,omitempty"`
+	To   *int   `json:"to,omitempty"`
+}
+
+// BatchResponse - ответ POST /v1/fibonacci/batch: результаты подзапросов в порядке их следования в запросе. Stats
+// содержит итоги всего пакета и передается только в подробном ответе.
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+	Stats   *Stats        `json:"stats,omitempty"`
+}
+
+// BatchResult - результат подзапроса: числа Фибоначчи Values с порядковыми номерами от From до To включительно.
+type BatchResult struct {
+	ID     string   `json:"id,omitempty"`
+	From   int      `json:"from"`
+	To     int      `json:"to"`
+	Values []string `json:"values"`
+	// Items - числа Values со сведениями об их получении, передаются только в подробном ответе.
+	Items []Item `json:"items,omitempty"`
+	// Error - ошибка подзапроса. При истечении времени вычисления Values содержит числа, вычисленные до его истечения.
+	Error *ErrorBody `json:"error,omitempty"`
+}
+
+// allowPost проверяет, что запрос r выполнен методом POST, и в противном случае отвечает статусом 405 Method Not
+// Allowed.
+func allowPost(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodPost {
+		return true
+	}
+
+	w.Header().Set("Allow", "POST")
+	WriteError(w, r, methodNotAllowed(r))
+	slog.WarnContext(r.Context(), "unsupported method", "client", clientName(r), "method", r.Method, "uri", r.URL.Path)
+	return false
+}
+
+// postFibBatch обрабатывает запросы POST /v1/fibonacci/batch и отправляет клиенту BatchResponse с результатами
+// подзапросов. Ошибки отдельных подзапросов передаются в их результатах; статус ответа отличается от 200 OK, только
+// если неверен или отклонен запрос целиком.
+func (s *Server) postFibBatch(w http.ResponseWriter, r *http.Request) {
+	if !allowPost(w, r) || !acceptable(w, r) {
+		return
+	}
+
+	start := time.Now()
+	verbose, err := isVerbose(r)
+	if err != nil {
+		WriteError(w, r, err)
+		slog.WarnContext(r.Context(), "wrong arguments", "client", clientName(r), "error", err)
+		return
+	}
+
+	req, err := parseBatch(w, r)
+	if err != nil {
+		WriteError(w, r, err)
+		slog.WarnContext(r.Context(), "wrong arguments", "client", clientName(r), "error", err)
+		return
+	}
+
+	var (
+		maxSize = s.MaxPageSize()
+		resp    = &BatchResponse{Results: make([]BatchResult, len(req.Queries))}
+		ranges  = make([]service.Range, 0, len(req.Queries))
+		queries = make([]int, 0, len(req.Queries))
+	)
+	for i, q := range req.Queries {
+		res := &resp.Results[i]
+		res.ID, res.Values = q.ID, []string{}
+
+		from, to, err := q.resolve(i, maxSize)
+		if err == nil {
+			res.From, res.To = from, to
+			err = s.guard.CheckRange(r.Context(), from, to)
+		}
+		if err != nil {
+			res.Error = toError(err).body()
+			slog.WarnContext(r.Context(), "batch query rejected", "client", clientName(r), "query", i, "error", err)
+			continue
+		}
+
+		ranges = append(ranges, service.Range{From: from, To: to})
+		queries = append(queries, i)
+	}
+
+	if total := service.Total(ranges); total > uint64(maxSize) {
+		err = invalidArgument("queries", "queries should request at most %d numbers in total, got %d", maxSize, total)
+		WriteError(w, r, err)
+		slog.WarnContext(r.Context(), "wrong arguments", "client", clientName(r), "error", err)
+		return
+	}
+
+	results, err := s.computeBatch(r, ranges)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	var items []service.Item
+	for k, res := range results {
+		out := &resp.Results[queries[k]]
+		out.Values = res.Values
+		if verbose || req.Verbose {
+			out.Items = newItems(res.Items)
+		}
+		if res.Err != nil {
+			n := int(service.Range{From: out.From, To: out.To}.Len())
+			out.Error = timeoutError(res.Err, len(res.Values), n).body()
+		}
+		items = append(items, res.Items...)
+	}
+	if verbose || req.Verbose {
+		stats := newStats(items, start)
+		resp.Stats = &stats
+	}
+
+	writeBody(w, r, http.StatusOK, resp)
+	slog.InfoContext(r.Context(), "sent fibonacci numbers", "client", clientName(r), "count", len(items),
+		"queries", len(req.Queries))
+}
+
+// parseBatch читает тело пакетного запроса r и проверяет количество подзапросов.
+func parseBatch(w http.ResponseWriter, r *http.Request) (*BatchRequest, error) {
+	var req BatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBody)).Decode(&req); err != nil {
+		return nil, invalidArgument("", "invalid request body: %v", err)
+	}
+
+	if len(req.Queries) == 0 || len(req.Queries) > service.MaxBatch {
+		return nil, invalidArgument("queries", "number of queries should be from 1 to %d, got %d", service.MaxBatch,
+			len(req.Queries))
+	}
+
+	return &req, nil
+}
+
+// resolve возвращает диапазон порядковых номеров подзапроса с номером i в пакете. Подзапрос должен содержать либо N,
+// либо From и To, и запрашивать не больше maxSize чисел.
+func (q BatchQuery) resolve(i, maxSize int) (int, int, error) {
+	param := fmt.Sprintf("queries[%d]", i)
+
+	switch {
+	case q.N != nil && q.From == nil && q.To == nil:
+		return *q.N, *q.N, nil
+	case q.N != nil:
+		return 0, 0, invalidArgument(param+".n", "n should not be set together with from and to")
+	case q.From == nil:
+		return 0, 0, invalidArgument(param+".from", "either n or from and to are required")
+	case q.To == nil:
+		return 0, 0, invalidArgument(param+".to", "to is required with from")
+	case *q.From > *q.To:
+		return 0, 0, invalidArgument(param+".from", "from (%d) should not be greater than to (%d)", *q.From, *q.To)
+	case service.Range{From: *q.From, To: *q.To}.Len() > uint64(maxSize):
+		return 0, 0, invalidArgument(param+".to", "range should contain at most %d numbers", maxSize)
+	}
+
+	return *q.From, *q.To, nil
+}
+
+// computeBatch вычисляет числа Фибоначчи для диапазонов ranges в пределах общего времени вычисления: дожидается
+// свободного места в планировщике со стоимостью объединенных диапазонов и учитывает время вычисления в квоте клиента.
+// Права клиента на каждый диапазон проверяются вызывающим.
+func (s *Server) computeBatch(r *http.Request, ranges []service.Range) ([]service.BatchResult, error) {
+	if len(ranges) == 0 {
+		return nil, nil
+	}
+
+	var (
+		cost   int64
+		merged = service.Merge(ranges)
+	)
+	for _, m := range merged {
+		cost = scheduler.AddCost(cost, scheduler.Cost(m.From, m.To))
+	}
+
+	var results []service.BatchResult
+	err := s.runner.Run(r.Context(), clientName(r), merged[0].From, merged[len(merged)-1].To, cost, s.Timeout(),
+		func(timeout time.Duration) error {
+			results = service.GetBatch(r.Context(), ranges, timeout, s.rdb)
+			return nil
+		})
+	return results, err
+}
+
+// records возвращает строки CSV index,value с ключом и границами подзапроса. Ошибка подзапроса записывается отдельной
+// строкой в столбец error. В подробном ответе строки дополняются столбцами digits, source и compute_time_us.
+func (r *BatchResponse) records() [][]string {
+	head := []string{"id", "from", "to", "index", "value", "error"}
+	if r.Stats != nil {
+		head = append(head, "digits", "source", "compute_time_us")
+	}
+
+	recs := [][]string{head}
+	for _, res := range r.Results {
+		from, to := strconv.Itoa(res.From), strconv.Itoa(res.To)
+		for i, val := range res.Values {
+			rec := []string{res.ID, from, to, strconv.Itoa(res.From + i), val, ""}
+			if r.Stats != nil {
+				it := res.Items[i]
+				rec = append(rec, strconv.Itoa(it.Digits), it.Source, strconv.FormatInt(it.ComputeTimeUs, 10))
+			}
+			recs = append(recs, rec)
+		}
+		if res.Error != nil {
+			rec := make([]string, len(head))
+			rec[0], rec[1], rec[2], rec[5] = res.ID, from, to, res.Error.Message
+			recs = append(recs, rec)
+		}
+	}
+	return recs
+}
+
+// rows возвращает значения всех подзапросов по порядку. Порядковые номера в текстовом формате не передаются.
+func (r *BatchResponse) rows() (int, []string) {
+	var values []string
+	for _, res := range r.Results {
+		values = append(values, res.Values...)
+	}
+	return 0, values
+}
+
+func (r *BatchResponse) message() *pb.Response {
+	var errs []string
+	for _, res := range r.Results {
+		if res.Error != nil {
+			errs = append(errs, res.Error.Message)
+		}
+	}
+
+	_, values := r.rows()
+	return &pb.Response{Data: values, Err: strings.Join(errs, "; ")}
+}
@@ -0,0 +1,131 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/rds"
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch(t *testing.T) {
+	rdb := rds.NewClient(redis.NewClient(&redis.Options{}), redisExpiration, 0)
+	srv := New(nil, nil, 200*time.Millisecond, rdb, scheduler.New(1, 10), nil)
+	srv.SetMaxPageSize(1000001)
+	h := srv.handler()
+
+	post := func(body, accept string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/fibonacci/batch", strings.NewReader(body))
+		req.Header.Set("Accept", accept)
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("results", func(t *testing.T) {
+		rec := post(`{"queries": [{"id": "a", "n": 10}, {"from": 8, "to": 11}, {"from": 3, "to": 1}, {"n": 1, "to": 2}]}`,
+			"")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp BatchResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Results, 4)
+		require.Equal(t, BatchResult{ID: "a", From: 10, To: 10, Values: []string{"55"}}, resp.Results[0])
+		require.Equal(t, BatchResult{From: 8, To: 11, Values: []string{"21", "34", "55", "89"}}, resp.Results[1])
+
+		require.Empty(t, resp.Results[2].Values)
+		require.Equal(t, CodeInvalidArgument, resp.Results[2].Error.Code)
+		require.Equal(t, "queries[2].from", resp.Results[2].Error.Details.Parameter)
+		require.Equal(t, "queries[3].n", resp.Results[3].Error.Details.Parameter)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		rec := post(`{"queries": [{"id": "a", "n": 10}, {"id": "b", "from": 3, "to": 1}]}`, MediaCSV)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "id,from,to,index,value,error\n"+
+			"a,10,10,10,55,\n"+
+			"b,0,0,,,from (3) should not be greater than to (1)\n", rec.Body.String())
+	})
+
+	t.Run("verbose", func(t *testing.T) {
+		rec := post(`{"verbose": true, "queries": [{"id": "a", "from": 10, "to": 11}, {"from": 3, "to": 1}]}`, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp BatchResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Results[0].Items, 2)
+		for i, it := range resp.Results[0].Items {
+			require.Equal(t, 10+i, it.Index)
+			require.Equal(t, resp.Results[0].Values[i], it.Value)
+			require.Equal(t, 2, it.Digits)
+			require.Equal(t, "computed", it.Source)
+		}
+		require.Empty(t, resp.Results[1].Items)
+		require.NotNil(t, resp.Stats)
+		require.Equal(t, 2, resp.Stats.Count)
+
+		rec = post(`{"queries": [{"id": "a", "n": 10}, {"id": "b", "from": 3, "to": 1}]}`, MediaCSV)
+		require.NotContains(t, rec.Body.String(), "digits")
+
+		rec = httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/fibonacci/batch?verbose=true",
+			strings.NewReader(`{"queries": [{"id": "a", "n": 10}, {"id": "b", "from": 3, "to": 1}]}`))
+		req.Header.Set("Accept", MediaCSV)
+		h.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		lines := strings.Split(rec.Body.String(), "\n")
+		require.Equal(t, "id,from,to,index,value,error,digits,source,compute_time_us", lines[0])
+		require.True(t, strings.HasPrefix(lines[1], "a,10,10,10,55,,2,computed,"), lines[1])
+		require.Equal(t, "b,0,0,,,from (3) should not be greater than to (1),,,", lines[2])
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		rec := post(`{"queries": [{"n": 5}, {"from": 0, "to": 1000000}]}`, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp BatchResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Equal(t, []string{"5"}, resp.Results[0].Values)
+		require.Nil(t, resp.Results[0].Error)
+
+		res := resp.Results[1]
+		require.Equal(t, CodeDeadlineExceeded, res.Error.Code)
+		require.Equal(t, len(res.Values), res.Error.Details.Progress.Completed)
+		require.Equal(t, 1000001, res.Error.Details.Progress.Expected)
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		rec := post(`{"queries": [{"from": 0, "to": 10000000000000}, {"from": -9223372036854775808, "to": 0}]}`, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp BatchResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		for i, res := range resp.Results {
+			require.Equal(t, CodeInvalidArgument, res.Error.Code, i)
+			require.Equal(t, fmt.Sprintf("queries[%d].to", i), res.Error.Details.Parameter)
+		}
+
+		rec = post(`{"queries": [{"from": 0, "to": 600000}, {"from": 1000000, "to": 1500000}]}`, "")
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		for _, body := range []string{`{"queries": []}`, `{"queries": [`, `[]`} {
+			rec := post(body, "")
+			require.Equal(t, http.StatusBadRequest, rec.Code, body)
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/fibonacci/batch", nil))
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		require.Equal(t, "POST", rec.Header().Get("Allow"))
+	})
+}
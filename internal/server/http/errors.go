@@ -0,0 +1,143 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/auth"
+	"github.com/dmitrykharchenko95/fibonacci/internal/quota"
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
+	"github.com/dmitrykharchenko95/fibonacci/internal/service"
+)
+
+// Коды ошибок, передаваемые клиенту в ErrorBody.Code.
+const (
+	CodeInvalidArgument   = "invalid_argument"
+	CodeUnauthenticated   = "unauthenticated"
+	CodePermissionDenied  = "permission_denied"
+	CodeNotFound          = "not_found"
+	CodeMethodNotAllowed  = "method_not_allowed"
+	CodeNotAcceptable     = "not_acceptable"
+	CodeResourceExhausted = "resource_exhausted"
+	CodeUnavailable       = "unavailable"
+	CodeDeadlineExceeded  = "deadline_exceeded"
+	CodeInternal          = "internal"
+)
+
+// Error - ошибка обработки запроса с HTTP статусом Status и описанием, передаваемым клиенту.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Details *ErrorDetails
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// ErrorResponse - ответ с ошибкой.
+type ErrorResponse struct {
+	Error *ErrorBody `json:"error"`
+}
+
+// ErrorBody описывает ошибку: машиночитаемый код Code (одна из констант Code...), текст Message и подробности Details.
+type ErrorBody struct {
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
+	Details *ErrorDetails `json:"details,omitempty"`
+}
+
+// ErrorDetails - подробности ошибки. Заполняются только поля, относящиеся к ошибке.
+type ErrorDetails struct {
+	// Parameter - неверный параметр запроса.
+	Parameter string `json:"parameter,omitempty"`
+	// Progress - количество вычисленных и запрошенных чисел при истечении времени вычисления.
+	Progress *Progress `json:"progress,omitempty"`
+	// RetryAfter - время в секундах, через которое запрос можно повторить.
+	RetryAfter int `json:"retry_after,omitempty"`
+}
+
+// Progress - количество вычисленных (Completed) и запрошенных (Expected) чисел.
+type Progress struct {
+	Completed int `json:"completed"`
+	Expected  int `json:"expected"`
+}
+
+// body возвращает описание ошибки для ответа клиенту.
+func (e *Error) body() *ErrorBody {
+	return &ErrorBody{Code: e.Code, Message: e.Message, Details: e.Details}
+}
+
+// invalidArgument возвращает ошибку 400 Bad Request для неверного параметра запроса param.
+func invalidArgument(param, format string, args ...interface{}) *Error {
+	e := &Error{Status: http.StatusBadRequest, Code: CodeInvalidArgument, Message: fmt.Sprintf(format, args...)}
+	if param != "" {
+		e.Details = &ErrorDetails{Parameter: param}
+	}
+	return e
+}
+
+// timeoutError возвращает ошибку истечения времени вычисления err, при котором из expected запрошенных чисел
+// вычислено completed. Если вычислена часть чисел, статус ошибки - 206 Partial Content, иначе 504 Gateway Timeout.
+func timeoutError(err error, completed, expected int) *Error {
+	status := http.StatusGatewayTimeout
+	if completed > 0 {
+		status = http.StatusPartialContent
+	}
+
+	return &Error{
+		Status:  status,
+		Code:    CodeDeadlineExceeded,
+		Message: err.Error(),
+		Details: &ErrorDetails{Progress: &Progress{Completed: completed, Expected: expected}},
+	}
+}
+
+// toError приводит ошибку err к Error, определяя HTTP статус и код по ошибкам пакетов сервиса. Неизвестные ошибки
+// считаются внутренними ошибками сервера.
+func toError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	e = &Error{Message: err.Error()}
+	switch {
+	case errors.Is(err, ErrWrongArgs):
+		e.Status, e.Code = http.StatusBadRequest, CodeInvalidArgument
+	case errors.Is(err, auth.ErrNoCredentials), errors.Is(err, auth.ErrInvalidCredentials):
+		e.Status, e.Code = http.StatusUnauthorized, CodeUnauthenticated
+	case errors.Is(err, auth.ErrForbidden):
+		e.Status, e.Code = http.StatusForbidden, CodePermissionDenied
+	case errors.Is(err, quota.ErrExceeded):
+		e.Status, e.Code = http.StatusTooManyRequests, CodeResourceExhausted
+	case errors.Is(err, scheduler.ErrQueueFull), errors.Is(err, scheduler.ErrQueueTimeout):
+		e.Status, e.Code = http.StatusServiceUnavailable, CodeUnavailable
+		e.Details = &ErrorDetails{RetryAfter: 1}
+	case errors.Is(err, service.ErrTimeoutExit):
+		e.Status, e.Code = http.StatusGatewayTimeout, CodeDeadlineExceeded
+	default:
+		e.Status, e.Code = http.StatusInternalServerError, CodeInternal
+	}
+	return e
+}
+
+// WriteError записывает в w ошибку err со статусом и кодом, определенными toError, в формате, выбранном по заголовку
+// Accept. На запросы к устаревшему эндпоинту "/" ошибка передается в структуре Response, на остальные - в
+// ErrorResponse. Если ошибка допускает повтор запроса, устанавливается заголовок Retry-After.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	e := toError(err)
+	if e.Details != nil && e.Details.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(e.Details.RetryAfter))
+	}
+
+	if r.URL.Path == "/" {
+		writeResponse(w, r, e.Status, &Response{Data: make([]string, 0), Err: e.Message, Error: e.body()})
+		return
+	}
+
+	writeBody(w, r, e.Status, &ErrorResponse{Error: e.body()})
+}
@@ -0,0 +1,81 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/auth"
+	"github.com/dmitrykharchenko95/fibonacci/internal/pagination"
+	"github.com/dmitrykharchenko95/fibonacci/internal/quota"
+	"github.com/dmitrykharchenko95/fibonacci/internal/rds"
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{fmt.Errorf("%w: x", ErrWrongArgs), http.StatusBadRequest, CodeInvalidArgument},
+		{auth.ErrNoCredentials, http.StatusUnauthorized, CodeUnauthenticated},
+		{fmt.Errorf("%w: scope", auth.ErrForbidden), http.StatusForbidden, CodePermissionDenied},
+		{quota.ErrExceeded, http.StatusTooManyRequests, CodeResourceExhausted},
+		{scheduler.ErrQueueFull, http.StatusServiceUnavailable, CodeUnavailable},
+		{invalidArgument("n", "bad n"), http.StatusBadRequest, CodeInvalidArgument},
+		{errors.New("boom"), http.StatusInternalServerError, CodeInternal},
+	}
+
+	for _, tt := range tests {
+		e := toError(tt.err)
+		require.Equal(t, tt.status, e.Status, tt.err.Error())
+		require.Equal(t, tt.code, e.Code, tt.err.Error())
+		require.Equal(t, tt.err.Error(), e.Message)
+	}
+
+	rec := httptest.NewRecorder()
+	WriteError(rec, httptest.NewRequest(http.MethodGet, "/v1/fibonacci", nil), scheduler.ErrQueueTimeout)
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+	var resp ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, &ErrorBody{Code: CodeUnavailable, Message: scheduler.ErrQueueTimeout.Error(),
+		Details: &ErrorDetails{RetryAfter: 1}}, resp.Error)
+}
+
+func TestPartialContent(t *testing.T) {
+	rdb := rds.NewClient(redis.NewClient(&redis.Options{}), redisExpiration, 0)
+	h := New(nil, nil, 200*time.Millisecond, rdb, scheduler.New(1, 10), nil).handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/fibonacci?from=0&to=1000000", nil))
+	require.Equal(t, http.StatusPartialContent, rec.Code)
+
+	var resp RangeResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotEmpty(t, resp.Values)
+	require.Equal(t, CodeDeadlineExceeded, resp.Error.Code)
+	require.Equal(t, &Progress{Completed: len(resp.Values), Expected: pagination.DefaultMaxSize},
+		resp.Error.Details.Progress)
+
+	completed := len(resp.Values)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet,
+		"/v1/fibonacci?from=0&to=1000000&page_size=5&page_token="+resp.NextPageToken, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, completed, resp.From)
+	require.Len(t, resp.Values, 5)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/fibonacci/100000000", nil))
+	require.Equal(t, http.StatusGatewayTimeout, rec.Code)
+}
@@ -1,42 +1,43 @@
 package httpserver
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
 	"github.com/dmitrykharchenko95/fibonacci/internal/service"
 )
 
 var ErrWrongArgs = errors.New("request's body should has two int values through a comma")
 
+// maxLegacyBody - наибольший размер тела запроса устаревшего эндпоинта "/" в байтах.
+const maxLegacyBody = 1 << 10
+
+// Response - ответ устаревшего эндпоинта "/". Err дублирует Error.Message для совместимости со старыми клиентами.
 type Response struct {
-	Data []string
-	Err  string
-}
+	Data  []string
+	Err   string
+	Error *ErrorBody `json:",omitempty"`
 
-// writeResponse осуществляет запись структуры Response в http.ResponseWriter в формате JSON.
-func writeResponse(w http.ResponseWriter, resp *Response) {
-	resBuf, err := json.Marshal(resp)
-	if err != nil {
-		log.Printf("response marshal error: %s", err)
-	}
-	_, err = w.Write(resBuf)
-	if err != nil {
-		log.Printf("response write error: %s", err)
-	}
+	// from - порядковый номер первого числа в Data.
+	from int
+}
 
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+// writeResponse записывает в w структуру Response с HTTP статусом code в формате, выбранном по заголовку Accept запроса
+// r (по умолчанию JSON).
+func writeResponse(w http.ResponseWriter, r *http.Request, code int, resp *Response) {
+	writeBody(w, r, code, resp)
 }
 
 // parseArgs принимает в качестве аргумента in строку вида "A,B", где А и В - целые числа, и при успешном выполнении
 // возвращает А и В (если А < B) или В и А (если А > В). При несоответствии in шаблону "A,B", возвращает 0,0 и
-// ошибку ErrWrongArgs.
+// ошибку, оборачивающую ErrWrongArgs.
 func parseArgs(in string) (int, int, error) {
 	args := strings.Split(in, ",")
 
@@ -46,12 +47,12 @@ func parseArgs(in string) (int, int, error) {
 
 	x, err := strconv.Atoi(args[0])
 	if err != nil {
-		return 0, 0, err
+		return 0, 0, fmt.Errorf("%w: %q is not an integer", ErrWrongArgs, args[0])
 	}
 
 	y, err := strconv.Atoi(args[1])
 	if err != nil {
-		return 0, 0, err
+		return 0, 0, fmt.Errorf("%w: %q is not an integer", ErrWrongArgs, args[1])
 	}
 
 	if x > y {
@@ -63,50 +64,79 @@ func parseArgs(in string) (int, int, error) {
 
 // getFib обрабатывает запросы к серверу и отправляет клиенту структуру Response с результатами выполнения
 // service.GetFibonacci в формате JSON. getFib обрабатывает только GET-запросы по адресу "host:port/". В теле запроса
-// ожидаются два целых числа через запятую.
+// ожидаются два целых числа через запятую, задающие диапазон не больше MaxPageSize чисел.
+//
+// Deprecated: эндпоинт сохранен для совместимости, новым клиентам следует использовать GET /rpc/v1/fibonacci (до даты
+// sunset) при подключенном HTTP/JSON шлюзе или GET /v1/fibonacci без него. Ответы содержат заголовки Deprecation, Link
+// на замену и, при подключенном шлюзе, Sunset.
 func (s *Server) getFib(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
 		http.NotFound(w, r)
 		return
 	}
 
-	resp := &Response{
-		Data: make([]string, 0),
+	if s.hasGateway() {
+		deprecate(w, successorPrefix+fibonacciPath)
+	} else {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+fibonacciPath+">; rel=\"successor-version\"")
 	}
 
 	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		resp.Err = fmt.Sprintf("method %s not supported on uri %s", r.Method, r.URL.Path)
-		writeResponse(w, resp)
-		log.Printf("%v: unsupported method <%v> on uri %v\n", r.RemoteAddr, r.Method, r.URL.Path)
+		WriteError(w, r, methodNotAllowed(r))
+		slog.WarnContext(r.Context(), "unsupported method", "client", clientName(r), "method", r.Method, "uri", r.URL.Path)
 		return
 	}
 
-	buf := make([]byte, r.ContentLength)
-	_, err := r.Body.Read(buf)
-	if err != nil && !errors.Is(err, io.EOF) {
-		w.WriteHeader(http.StatusBadRequest)
-		resp.Err = err.Error()
-		writeResponse(w, resp)
-		log.Printf("%v: reading request body failed: %v\n", r.RemoteAddr, err)
+	buf, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxLegacyBody))
+	if err != nil {
+		WriteError(w, r, invalidArgument("", "read request body: %v", err))
+		slog.WarnContext(r.Context(), "reading request body failed", "client", clientName(r), "error", err)
 		return
 	}
 
 	x, y, err := parseArgs(string(buf))
+	if n, maxSize := (service.Range{From: x, To: y}).Len(), s.MaxPageSize(); err == nil && n > uint64(maxSize) {
+		err = invalidArgument("", "request should contain at most %d numbers, got %d", maxSize, n)
+	}
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		resp.Err = err.Error()
-		writeResponse(w, resp)
-		log.Printf("%v: wrong arguments: %v\n", r.RemoteAddr, err)
+		WriteError(w, r, err)
+		slog.WarnContext(r.Context(), "wrong arguments", "client", clientName(r), "error", err)
 		return
 	}
-	data, err := service.GetFibonacci(x, y, s.timeout, s.rdb)
+
+	if !acceptable(w, r) {
+		return
+	}
+
+	items, err := s.compute(r, x, y)
+	resp, status := &Response{Data: service.Values(items), from: x}, http.StatusOK
 	if err != nil {
-		resp.Data, resp.Err = data, err.Error()
-	} else {
-		resp.Data = data
+		e := toError(err)
+		if e.Status != http.StatusPartialContent {
+			WriteError(w, r, e)
+			return
+		}
+		resp.Err, resp.Error, status = e.Message, e.body(), e.Status
+	}
+
+	writeResponse(w, r, status, resp)
+	slog.InfoContext(r.Context(), "sent fibonacci numbers", "client", clientName(r), "count", len(resp.Data))
+}
+
+// compute вычисляет числа Фибоначчи с порядковыми номерами от x до y включительно: проверяет права клиента на диапазон,
+// дожидается свободного места в планировщике и учитывает время вычисления в квоте клиента. При истечении времени
+// вычисления compute возвращает числа, вычисленные до его истечения, и ошибку timeoutError.
+func (s *Server) compute(r *http.Request, x, y int) ([]service.Item, error) {
+	var items []service.Item
+	err := s.runner.Run(r.Context(), clientName(r), x, y, scheduler.Cost(x, y), s.Timeout(),
+		func(timeout time.Duration) (err error) {
+			items, err = service.GetFibonacciItems(r.Context(), x, y, timeout, s.rdb)
+			return err
+		})
+	if errors.Is(err, service.ErrTimeoutExit) {
+		return items, timeoutError(err, len(items), y-x+1)
 	}
 
-	writeResponse(w, resp)
-	log.Printf("%v: sended %v numbers fibonacci\n", r.RemoteAddr, len(resp.Data))
+	return items, err
 }
@@ -0,0 +1,44 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/health"
+)
+
+// Liveness возвращает обработчик проверки живости, отвечающий статусом 200 OK, пока процесс обрабатывает запросы.
+func Liveness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+}
+
+// Readiness возвращает обработчик проверки готовности, отвечающий статусом 200 OK, если c считает сервер готовым, и
+// 503 Service Unavailable в противном случае. В теле ответа передается отчет health.Report.
+func Readiness(c *health.Checker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rep := c.Ready(r.Context())
+
+		code := http.StatusOK
+		if !rep.Ready {
+			code = http.StatusServiceUnavailable
+		}
+		WriteJSON(w, code, rep)
+	})
+}
+
+// writeJSON записывает в w значение v в формате JSON с HTTP статусом code.
+func WriteJSON(w http.ResponseWriter, code int, v interface{}) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		slog.Error("response marshal error", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	if _, err = w.Write(buf); err != nil {
+		slog.Error("response write error", "error", err)
+	}
+}
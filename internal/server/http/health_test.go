@@ -0,0 +1,32 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/health"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadiness(t *testing.T) {
+	c := health.New(time.Second)
+	h := Readiness(c)
+
+	do := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return rec
+	}
+
+	require.Equal(t, http.StatusServiceUnavailable, do().Code)
+
+	c.WarmedUp()
+	rec := do()
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"ready":true,"checks":{"shutdown":"ok","warmup":"ok"}}`, rec.Body.String())
+
+	c.Drain()
+	require.Equal(t, http.StatusServiceUnavailable, do().Code)
+}
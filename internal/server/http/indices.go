@@ -0,0 +1,163 @@
+package httpserver
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
+	"github.com/dmitrykharchenko95/fibonacci/internal/server/grpc/pb"
+	"github.com/dmitrykharchenko95/fibonacci/internal/service"
+)
+
+// IndicesResponse - ответ GET /v1/fibonacci?indices= и GET /v1/fibonacci?from=&to=&step=: числа Фибоначчи Values с
+// порядковыми номерами Indices в порядке запроса.
+type IndicesResponse struct {
+	Indices []int    `json:"indices"`
+	Values  []string `json:"values"`
+	// Error - ошибка, из-за которой вычислена только часть чисел (ответ со статусом 206 Partial Content).
+	Error *ErrorBody `json:"error,omitempty"`
+}
+
+// isIndexSet сообщает, запрошено ли в r множество порядковых номеров (список indices или прогрессия с шагом step), а не
+// непрерывный диапазон.
+func isIndexSet(r *http.Request) bool {
+	q := r.URL.Query()
+	return q.Has("indices") || q.Has("step")
+}
+
+// getFibIndices обрабатывает запросы GET /v1/fibonacci?indices= и GET /v1/fibonacci?from=&to=&step= и отправляет
+// клиенту IndicesResponse, а с параметром verbose=true - VerboseResponse. Если до истечения времени вычислена только
+// часть чисел, они передаются со статусом 206 Partial Content и описанием ошибки.
+func (s *Server) getFibIndices(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	verbose, err := isVerbose(r)
+	if err != nil {
+		WriteError(w, r, err)
+		slog.WarnContext(r.Context(), "wrong arguments", "client", clientName(r), "error", err)
+		return
+	}
+
+	indices, err := parseIndices(r)
+	if err != nil {
+		WriteError(w, r, err)
+		slog.WarnContext(r.Context(), "wrong arguments", "client", clientName(r), "error", err)
+		return
+	}
+
+	items, err := s.computeIndices(r, indices)
+	resp, status := &IndicesResponse{Indices: indices, Values: service.Values(items)}, http.StatusOK
+	if err != nil {
+		e := toError(err)
+		if e.Status != http.StatusPartialContent {
+			WriteError(w, r, e)
+			return
+		}
+		resp.Error, status = e.body(), e.Status
+	}
+
+	var body interface{} = resp
+	if verbose {
+		body = newVerbose(items, start, "", resp.Error)
+	}
+
+	writeBody(w, r, status, body)
+	slog.InfoContext(r.Context(), "sent fibonacci numbers", "client", clientName(r), "count", len(items))
+}
+
+// parseIndices возвращает порядковые номера из параметра indices (номера через запятую) или арифметической прогрессии
+// с параметрами from, to и step. Количество номеров не может превышать service.MaxIndices.
+func parseIndices(r *http.Request) ([]int, error) {
+	q := r.URL.Query()
+
+	if q.Has("indices") {
+		if q.Has("from") || q.Has("to") || q.Has("step") {
+			return nil, invalidArgument("indices",
+				"parameter indices should not be set together with from, to and step")
+		}
+
+		list := strings.Split(q.Get("indices"), ",")
+		if len(list) > service.MaxIndices {
+			return nil, invalidArgument("indices", "parameter indices should contain at most %d indices",
+				service.MaxIndices)
+		}
+
+		indices := make([]int, len(list))
+		for i, val := range list {
+			n, err := parseIndex("indices", strings.TrimSpace(val))
+			if err != nil {
+				return nil, err
+			}
+			indices[i] = n
+		}
+		return indices, nil
+	}
+
+	from, err := parseIndex("from", q.Get("from"))
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := parseIndex("to", q.Get("to"))
+	if err != nil {
+		return nil, err
+	}
+
+	step, err := parseIndex("step", q.Get("step"))
+	if err != nil {
+		return nil, err
+	}
+
+	indices, ok := service.Progression(from, to, step)
+	if !ok {
+		return nil, invalidArgument("step",
+			"parameter step should be non-zero, lead from %d to %d and give at most %d indices", from, to,
+			service.MaxIndices)
+	}
+	return indices, nil
+}
+
+// computeIndices вычисляет числа Фибоначчи с порядковыми номерами indices: проверяет права клиента на номера,
+// дожидается свободного места в планировщике и учитывает время вычисления в квоте клиента.
+func (s *Server) computeIndices(r *http.Request, indices []int) ([]service.Item, error) {
+	var (
+		cost   int64
+		lo, hi = indices[0], indices[0]
+	)
+	for _, i := range indices {
+		cost = scheduler.AddCost(cost, scheduler.Cost(i, i))
+		lo, hi = min(lo, i), max(hi, i)
+	}
+
+	var items []service.Item
+	err := s.runner.Run(r.Context(), clientName(r), lo, hi, cost, s.Timeout(), func(timeout time.Duration) (err error) {
+		items, err = service.GetIndicesItems(r.Context(), indices, timeout, s.rdb)
+		return err
+	})
+	if errors.Is(err, service.ErrTimeoutExit) {
+		return items, timeoutError(err, len(items), len(indices))
+	}
+
+	return items, err
+}
+
+// records возвращает строки CSV index,value для вычисленных чисел.
+func (r *IndicesResponse) records() [][]string {
+	recs := [][]string{{"index", "value"}}
+	for i, val := range r.Values {
+		recs = append(recs, []string{strconv.Itoa(r.Indices[i]), val})
+	}
+	return recs
+}
+
+// rows возвращает значения для текстового формата; CSV записывается с порядковыми номерами через records.
+func (r *IndicesResponse) rows() (int, []string) {
+	return 0, r.Values
+}
+
+func (r *IndicesResponse) message() *pb.Response {
+	return &pb.Response{Data: r.Values}
+}
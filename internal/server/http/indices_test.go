@@ -0,0 +1,84 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/rds"
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndices(t *testing.T) {
+	rdb := rds.NewClient(redis.NewClient(&redis.Options{}), redisExpiration, 0)
+	h := New(nil, nil, timeout, rdb, scheduler.New(1, 10), nil).handler()
+
+	get := func(target, accept string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept", accept)
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		want   IndicesResponse
+	}{
+		{
+			name:   "list",
+			target: "/v1/fibonacci?indices=10,-6,100,10",
+			want: IndicesResponse{Indices: []int{10, -6, 100, 10},
+				Values: []string{"55", "-8", "354224848179261915075", "55"}},
+		},
+		{
+			name:   "progression",
+			target: "/v1/fibonacci?from=5&to=30&step=10",
+			want:   IndicesResponse{Indices: []int{5, 15, 25}, Values: []string{"5", "610", "75025"}},
+		},
+		{
+			name:   "descending",
+			target: "/v1/fibonacci?from=3&to=0&step=-1",
+			want:   IndicesResponse{Indices: []int{3, 2, 1, 0}, Values: []string{"2", "1", "1", "0"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := get(tt.target, "")
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var resp IndicesResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			require.Equal(t, tt.want, resp)
+		})
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		rec := get("/v1/fibonacci?indices=10,1", MediaCSV)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "index,value\n10,55\n1,1\n", rec.Body.String())
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		for _, target := range []string{
+			"/v1/fibonacci?indices=1,x",
+			"/v1/fibonacci?indices=",
+			"/v1/fibonacci?indices=1&from=0",
+			"/v1/fibonacci?from=0&to=10&step=0",
+			"/v1/fibonacci?from=0&to=10&step=-1",
+			"/v1/fibonacci?from=0&to=1000000&step=1",
+			"/v1/fibonacci?to=10&step=1",
+		} {
+			rec := get(target, "")
+			require.Equal(t, http.StatusBadRequest, rec.Code, target)
+
+			var resp ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			require.Equal(t, CodeInvalidArgument, resp.Error.Code, target)
+		}
+	})
+}
@@ -0,0 +1,304 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/auth"
+	"github.com/dmitrykharchenko95/fibonacci/internal/logger"
+	"github.com/dmitrykharchenko95/fibonacci/internal/metrics"
+	"github.com/dmitrykharchenko95/fibonacci/internal/quota"
+	"github.com/dmitrykharchenko95/fibonacci/internal/ratelimit"
+	"github.com/dmitrykharchenko95/fibonacci/internal/tlsconfig"
+	"github.com/dmitrykharchenko95/fibonacci/internal/tracing"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware - обертка над http.Handler, выполняемая до обработки запроса хэндлерами сервера.
+type Middleware func(http.Handler) http.Handler
+
+type routeKey struct{}
+
+// route возвращает шаблон mux, которому соответствует запрос r, или путь запроса, если шаблон не определен.
+func route(r *http.Request) string {
+	if pattern, ok := r.Context().Value(routeKey{}).(string); ok {
+		return pattern
+	}
+	return r.URL.Path
+}
+
+// instrument оборачивает h сбором метрик запросов. Эндпоинт определяется по шаблону mux, которому соответствует
+// запрос, чтобы количество значений метки не зависело от запрошенных путей. Шаблон сохраняется в контексте запроса
+// (route).
+func instrument(mux *http.ServeMux, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		_, pattern := mux.Handler(r)
+		if pattern == "" {
+			pattern = "unknown"
+		} else {
+			r = r.WithContext(context.WithValue(r.Context(), routeKey{}, pattern))
+		}
+
+		rw := &responseWriter{ResponseWriter: w}
+		h.ServeHTTP(rw, r)
+
+		if rw.status == 0 {
+			rw.status = http.StatusOK
+		}
+		code := strconv.Itoa(rw.status)
+
+		metrics.Requests.WithLabelValues("http", pattern, code).Inc()
+		metrics.RequestDuration.WithLabelValues("http", code).Observe(time.Since(start).Seconds())
+		metrics.ResponseSize.WithLabelValues("http").Observe(float64(rw.n))
+	})
+}
+
+const (
+	// RequestIDHeader - заголовок с идентификатором запроса.
+	RequestIDHeader = "X-Request-ID"
+	// LogLevelHeader - заголовок, значение debug которого включает отладочное логирование запроса.
+	LogLevelHeader = "X-Log-Level"
+)
+
+// RequestID возвращает Middleware, сохраняющий в контексте запроса идентификатор из заголовка X-Request-ID или
+// сгенерированный новый. Идентификатор возвращается клиенту в том же заголовке и добавляется во все записи лога,
+// относящиеся к запросу. Заголовок X-Log-Level: debug включает отладочное логирование для отдельного запроса.
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" {
+				id = logger.NewRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+
+			ctx := logger.WithRequestID(r.Context(), id)
+			if strings.EqualFold(r.Header.Get(LogLevelHeader), "debug") {
+				ctx = logger.WithDebug(ctx)
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Tracing возвращает Middleware, создающий серверный спан для каждого запроса. Контекст трассировки, переданный
+// клиентом в заголовках traceparent и tracestate, используется как родительский.
+func Tracing() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := tracing.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracing.Start(ctx, "HTTP "+r.Method, trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest("", r.URL.Path, r)...))
+			defer span.End()
+
+			rw := &responseWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			if rw.status == 0 {
+				rw.status = http.StatusOK
+			}
+			span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(rw.status)...)
+			if rw.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(rw.status))
+			}
+		})
+	}
+}
+
+// Authenticate возвращает Middleware, проверяющий учетные данные клиента: API ключ из заголовка apiKeyHeader или bearer
+// токен из заголовка Authorization. Identity клиента сохраняется в контексте запроса (auth.FromContext). На запросы с
+// неверными или отсутствующими учетными данными сервер отвечает статусом 401 Unauthorized. Если lim не nil, неудачные
+// попытки учитываются по IP адресу клиента в лимите эндпоинта ratelimit.AuthFailures; после его исчерпания запросы с
+// этого адреса отклоняются без проверки со статусом 429 Too Many Requests.
+func Authenticate(g *auth.Guard, apiKeyHeader string, lim *ratelimit.Limiter) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := "ip:" + remoteIP(r)
+			if lim != nil {
+				if blocked, retry := lim.Blocked(ratelimit.AuthFailures, ip); blocked {
+					WriteError(w, r, &Error{Status: http.StatusTooManyRequests, Code: CodeResourceExhausted,
+						Message: "too many failed authentication attempts",
+						Details: &ErrorDetails{RetryAfter: ratelimit.RetryAfter(retry)}})
+					slog.WarnContext(r.Context(), "authentication throttled", "client", clientName(r))
+					return
+				}
+			}
+
+			cred := auth.Credentials{
+				APIKey: r.Header.Get(apiKeyHeader),
+			}
+			if h := r.Header.Get("Authorization"); len(h) > 7 && strings.EqualFold(h[:7], "bearer ") {
+				cred.Bearer = h[7:]
+			}
+
+			id, err := g.Authenticate(r.Context(), cred)
+			if err != nil {
+				if lim != nil {
+					lim.Fail(r.Context(), ratelimit.AuthFailures, ip)
+				}
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				WriteError(w, r, &Error{Status: http.StatusUnauthorized, Code: CodeUnauthenticated, Message: err.Error()})
+				slog.WarnContext(r.Context(), "authentication failed", "client", clientName(r), "error", err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), id)))
+		})
+	}
+}
+
+// RequireScope возвращает Middleware, допускающий к обработчику только клиентов с областью доступа scope. Должен
+// применяться после Authenticate.
+func RequireScope(scope string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := auth.RequireScope(r.Context(), scope); err != nil {
+				WriteError(w, r, err)
+				slog.WarnContext(r.Context(), "access denied", "client", clientName(r), "uri", r.URL.Path, "error", err)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimit возвращает Middleware, ограничивающий частоту запросов клиента к каждому эндпоинту. Эндпоинт определяется
+// по шаблону маршрута, например /v1/fibonacci/ для всех запросов /v1/fibonacci/{n}. Клиент определяется по
+// Identity из контекста запроса, API ключу из заголовка keyHeader, если guard подтверждает его, или IP адресу. Ключи,
+// не прошедшие проверку (в том числе при nil guard), не учитываются. На запросы сверх лимита сервер отвечает статусом
+// 429 Too Many Requests.
+func RateLimit(lim *ratelimit.Limiter, keyHeader string, guard *auth.Guard) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := clientKey(r, keyHeader, guard)
+
+			ok, retry := lim.Allow(r.Context(), route(r), client)
+			if !ok {
+				WriteError(w, r, &Error{Status: http.StatusTooManyRequests, Code: CodeResourceExhausted,
+					Message: "rate limit exceeded", Details: &ErrorDetails{RetryAfter: ratelimit.RetryAfter(retry)}})
+				slog.WarnContext(r.Context(), "rate limit exceeded", "client", clientName(r), "uri", r.URL.Path)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Quota возвращает Middleware, учитывающий время вычислений и объем отправленных данных клиента и отклоняющий запросы
+// клиентов, исчерпавших квоты, со статусом 429 Too Many Requests. Оставшийся бюджет передается в заголовках
+// X-Quota-Compute-Remaining (в миллисекундах) и X-Quota-Bytes-Remaining.
+func Quota(m *quota.Manager) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := quotaKey(r)
+
+			rem, err := m.Check(r.Context(), client)
+			if rem.Compute >= 0 {
+				w.Header().Set("X-Quota-Compute-Remaining", strconv.FormatInt(rem.Compute.Milliseconds(), 10))
+			}
+			if rem.Bytes >= 0 {
+				w.Header().Set("X-Quota-Bytes-Remaining", strconv.FormatInt(rem.Bytes, 10))
+			}
+
+			if err != nil {
+				WriteError(w, r, err)
+				slog.WarnContext(r.Context(), "quota exceeded", "client", clientName(r), "error", err)
+				return
+			}
+
+			meter := &quota.Meter{}
+			rw := &responseWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r.WithContext(quota.NewContext(r.Context(), meter)))
+
+			m.Record(r.Context(), client, quota.Usage{Compute: meter.Compute(), Bytes: rw.n})
+		})
+	}
+}
+
+// responseWriter запоминает статус ответа и подсчитывает количество байт, записанных в http.ResponseWriter.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	n      int64
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.n += int64(n)
+	return n, err
+}
+
+// quotaKey возвращает идентификатор клиента для учета квот: имя аутентифицированного клиента или IP адрес
+// анонимного клиента.
+func quotaKey(r *http.Request) string {
+	if id := auth.FromContext(r.Context()); id != nil && id.Subject != "" {
+		return id.Subject
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+// clientKey возвращает идентификатор клиента: имя аутентифицированного клиента, имя клиента с API ключом из заголовка
+// keyHeader, подтвержденным guard, имя из клиентского сертификата или IP адрес клиента. Сам ключ в идентификатор не
+// попадает.
+func clientKey(r *http.Request, keyHeader string, guard *auth.Guard) string {
+	if id := auth.FromContext(r.Context()); id != nil && id.Subject != "" {
+		return "id:" + id.Subject
+	}
+
+	if id := guard.KeyIdentity(r.Context(), r.Header.Get(keyHeader)); id != nil {
+		return "id:" + id.Subject
+	}
+
+	if name := tlsconfig.PeerName(r.TLS); name != "" {
+		return "peer:" + name
+	}
+
+	return "ip:" + remoteIP(r)
+}
+
+// remoteIP возвращает IP адрес клиента без порта.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// clientName возвращает адрес клиента для логов, дополненный именем из клиентского сертификата при mTLS.
+func clientName(r *http.Request) string {
+	if name := tlsconfig.PeerName(r.TLS); name != "" {
+		return fmt.Sprintf("%v (%v)", r.RemoteAddr, name)
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,123 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/auth"
+	"github.com/dmitrykharchenko95/fibonacci/internal/logger"
+	"github.com/dmitrykharchenko95/fibonacci/internal/ratelimit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRateLimit(t *testing.T) {
+	lim := ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.Limit{Rate: 0.001, Burst: 1}, nil)
+	guard := &auth.Guard{
+		Authenticator:  auth.NewAPIKeys([]auth.APIKey{{Key: "key1", Name: "client1"}}),
+		AllowAnonymous: true,
+	}
+	h := RateLimit(lim, "X-API-Key", guard)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	require.Equal(t, http.StatusOK, do("").Code)
+
+	rec := do("")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	require.Equal(t, http.StatusOK, do("key1").Code)
+	require.Equal(t, http.StatusTooManyRequests, do("key1").Code)
+
+	require.Equal(t, http.StatusTooManyRequests, do("key2").Code)
+}
+
+func TestRequestID(t *testing.T) {
+	var id string
+	h := RequestID()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = logger.RequestID(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.NotEmpty(t, id)
+	require.Equal(t, id, rec.Header().Get(RequestIDHeader))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, "abc", id)
+	require.Equal(t, "abc", rec.Header().Get(RequestIDHeader))
+}
+
+func TestAuthenticate(t *testing.T) {
+	g := &auth.Guard{
+		Authenticator: auth.NewAPIKeys([]auth.APIKey{
+			{Key: "user", Name: "user"},
+			{Key: "admin", Name: "admin", Scopes: []string{auth.ScopeAdmin}},
+		}),
+	}
+	lim := ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.Limit{}, map[string]ratelimit.Limit{
+		ratelimit.AuthFailures: {Rate: 0.01, Burst: 2},
+	})
+	h := Authenticate(g, "X-API-Key", lim)(RequireScope(auth.ScopeAdmin)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	do := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	require.Equal(t, http.StatusUnauthorized, do("").Code)
+	require.Equal(t, http.StatusUnauthorized, do("wrong").Code)
+	require.Equal(t, http.StatusForbidden, do("user").Code)
+	require.Equal(t, http.StatusOK, do("admin").Code)
+
+	// Третья неудачная попытка исчерпывает лимит, после чего запросы с адреса отклоняются без проверки ключа.
+	require.Equal(t, http.StatusUnauthorized, do("wrong").Code)
+	rec := do("admin")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.NotEmpty(t, rec.Header().Get("Retry-After"))
+}
+
+func TestTracing(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	h := Tracing()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "HTTP GET", spans[0].Name())
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+}
@@ -0,0 +1,271 @@
+package httpserver
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/server/grpc/pb"
+	"github.com/fxamacker/cbor/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+// Медиатипы ответов HTTP сервера.
+const (
+	MediaJSON     = "application/json"
+	MediaCSV      = "text/csv"
+	MediaText     = "text/plain"
+	MediaCBOR     = "application/cbor"
+	MediaProtobuf = "application/x-protobuf"
+)
+
+// notAcceptable - ошибка 406 Not Acceptable со списком поддерживаемых форматов.
+var notAcceptable = &Error{Status: http.StatusNotAcceptable, Code: CodeNotAcceptable,
+	Message: "supported media types: " + strings.Join([]string{MediaJSON, MediaCSV, MediaText, MediaCBOR, MediaProtobuf}, ", ")}
+
+// format - формат ответа: медиатип, принимаемые для него синонимы в заголовке Accept и функция кодирования.
+type format struct {
+	mediaType   string
+	contentType string
+	aliases     []string
+	encode      func(w io.Writer, v interface{}) error
+}
+
+// formats - поддерживаемые форматы ответов. При равных предпочтениях клиента выбирается формат, указанный раньше.
+var formats = []format{
+	{mediaType: MediaJSON, contentType: MediaJSON + "; charset=utf-8", encode: encodeJSON},
+	{mediaType: MediaCSV, contentType: MediaCSV + "; charset=utf-8", encode: encodeCSV},
+	{mediaType: MediaText, contentType: MediaText + "; charset=utf-8", encode: encodeText},
+	{mediaType: MediaCBOR, contentType: MediaCBOR, encode: encodeCBOR},
+	{mediaType: MediaProtobuf, contentType: MediaProtobuf, encode: encodeProto,
+		aliases: []string{"application/protobuf", "application/vnd.google.protobuf"}},
+}
+
+// specificity возвращает точность совпадения диапазона медиатипов media из заголовка Accept с форматом f: 2 - точное
+// совпадение, 1 - совпадение по type/*, 0 - */*, -1 - формат не подходит.
+func (f format) specificity(media string) int {
+	if media == f.mediaType {
+		return 2
+	}
+	for _, a := range f.aliases {
+		if media == a {
+			return 2
+		}
+	}
+
+	typ, _, _ := strings.Cut(f.mediaType, "/")
+	switch media {
+	case typ + "/*":
+		return 1
+	case "*/*":
+		return 0
+	}
+	return -1
+}
+
+// negotiate выбирает формат ответа на запрос r по заголовку Accept. Для каждого формата учитывается вес q наиболее
+// точно совпадающего с ним диапазона; при равных весах выбирается диапазон, указанный клиентом раньше. Без заголовка
+// Accept выбирается JSON. Второе значение false означает, что ни один из поддерживаемых форматов не подходит клиенту.
+func negotiate(r *http.Request) (format, bool) {
+	accept := strings.Join(r.Header.Values("Accept"), ",")
+	if strings.TrimSpace(accept) == "" {
+		return formats[0], true
+	}
+
+	var (
+		best    = -1
+		bestQ   float64
+		bestPos int
+		ranges  = strings.Split(accept, ",")
+	)
+	for i, f := range formats {
+		q, pos, spec := 0.0, 0, -1
+		for p, rng := range ranges {
+			media, params, _ := strings.Cut(rng, ";")
+			s := f.specificity(strings.ToLower(strings.TrimSpace(media)))
+			if s > spec {
+				q, pos, spec = acceptWeight(params), p, s
+			}
+		}
+
+		if q > 0 && (best < 0 || q > bestQ || q == bestQ && pos < bestPos) {
+			best, bestQ, bestPos = i, q, pos
+		}
+	}
+
+	if best < 0 {
+		return formats[0], false
+	}
+	return formats[best], true
+}
+
+// acceptWeight возвращает вес q из параметров params диапазона медиатипов. Без параметра q вес равен 1.
+func acceptWeight(params string) float64 {
+	for _, p := range strings.Split(params, ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+		if ok && strings.EqualFold(strings.TrimSpace(k), "q") {
+			q, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return 0
+			}
+			return q
+		}
+	}
+	return 1
+}
+
+// acceptable проверяет, что клиенту подходит один из поддерживаемых форматов ответа, и в противном случае отвечает
+// статусом 406 Not Acceptable.
+func acceptable(w http.ResponseWriter, r *http.Request) bool {
+	if _, ok := negotiate(r); ok {
+		return true
+	}
+
+	WriteError(w, r, notAcceptable)
+	slog.WarnContext(r.Context(), "not acceptable", "client", clientName(r), "accept", r.Header.Get("Accept"))
+	return false
+}
+
+// writeBody записывает в w ответ v с HTTP статусом code в формате, выбранном по заголовку Accept запроса r.
+func writeBody(w http.ResponseWriter, r *http.Request, code int, v interface{}) {
+	f, _ := negotiate(r)
+
+	w.Header().Set("Content-Type", f.contentType)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(code)
+
+	if err := f.encode(w, v); err != nil {
+		slog.ErrorContext(r.Context(), "response write error", "format", f.mediaType, "error", err)
+	}
+}
+
+// tabular - ответ, содержащий числа Фибоначчи с последовательными порядковыми номерами, начиная с первого значения.
+type tabular interface {
+	rows() (int, []string)
+}
+
+// table - ответ, записываемый в CSV собственными столбцами. Первая запись содержит заголовок.
+type table interface {
+	records() [][]string
+}
+
+// failure - ответ с ошибкой. Второе значение false означает, что ответ содержит данные и передается как tabular.
+type failure interface {
+	failure() (string, bool)
+}
+
+// message - ответ, представимый сообщением response из proto/fibonacci.proto.
+type message interface {
+	message() *pb.Response
+}
+
+func encodeJSON(w io.Writer, v interface{}) error {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf)
+	return err
+}
+
+func encodeCBOR(w io.Writer, v interface{}) error {
+	return cbor.NewEncoder(w).Encode(v)
+}
+
+func encodeProto(w io.Writer, v interface{}) error {
+	buf, err := proto.Marshal(v.(message).message())
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf)
+	return err
+}
+
+// encodeCSV записывает ответ строками index,value с заголовком. Ошибка записывается в столбец error. Ответы table
+// записываются собственными столбцами.
+func encodeCSV(w io.Writer, v interface{}) error {
+	cw := csv.NewWriter(w)
+
+	if t, ok := v.(table); ok {
+		return cw.WriteAll(t.records())
+	}
+
+	if f, ok := v.(failure); ok {
+		if msg, ok := f.failure(); ok {
+			cw.Write([]string{"error"})
+			cw.Write([]string{msg})
+			cw.Flush()
+			return cw.Error()
+		}
+	}
+
+	first, values := v.(tabular).rows()
+	cw.Write([]string{"index", "value"})
+	for i, val := range values {
+		cw.Write([]string{strconv.Itoa(first + i), val})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// encodeText записывает значения ответа по одному в строке. Ошибка записывается текстом.
+func encodeText(w io.Writer, v interface{}) error {
+	if f, ok := v.(failure); ok {
+		if msg, ok := f.failure(); ok {
+			_, err := io.WriteString(w, msg+"\n")
+			return err
+		}
+	}
+
+	_, values := v.(tabular).rows()
+	var sb strings.Builder
+	for _, val := range values {
+		sb.WriteString(val)
+		sb.WriteByte('\n')
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (r *Response) rows() (int, []string) {
+	return r.from, r.Data
+}
+
+func (r *Response) failure() (string, bool) {
+	return r.Err, r.Err != "" && len(r.Data) == 0
+}
+
+func (r *Response) message() *pb.Response {
+	return &pb.Response{Data: r.Data, Err: r.Err}
+}
+
+func (r *NumberResponse) rows() (int, []string) {
+	return r.N, []string{r.Value}
+}
+
+func (r *NumberResponse) message() *pb.Response {
+	return &pb.Response{Data: []string{r.Value}}
+}
+
+func (r *RangeResponse) rows() (int, []string) {
+	return r.From, r.Values
+}
+
+func (r *RangeResponse) message() *pb.Response {
+	return &pb.Response{Data: r.Values}
+}
+
+func (r *ErrorResponse) failure() (string, bool) {
+	return r.Error.Message, true
+}
+
+func (r *ErrorResponse) message() *pb.Response {
+	return &pb.Response{Err: r.Error.Message}
+}
@@ -0,0 +1,98 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/rds"
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
+	"github.com/dmitrykharchenko95/fibonacci/internal/server/grpc/pb"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		media  string
+		ok     bool
+	}{
+		{"", MediaJSON, true},
+		{"*/*", MediaJSON, true},
+		{"text/csv", MediaCSV, true},
+		{"text/*", MediaCSV, true},
+		{"text/plain, text/csv", MediaText, true},
+		{"application/json;q=0.5, application/cbor", MediaCBOR, true},
+		{"application/protobuf", MediaProtobuf, true},
+		{"*/*;q=0.1, application/json;q=0", MediaCSV, true},
+		{"image/png", MediaJSON, false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+
+		f, ok := negotiate(r)
+		require.Equal(t, tt.ok, ok, tt.accept)
+		require.Equal(t, tt.media, f.mediaType, tt.accept)
+	}
+}
+
+func TestContentNegotiation(t *testing.T) {
+	rdb := rds.NewClient(redis.NewClient(&redis.Options{}), redisExpiration, 0)
+	h := New(nil, nil, timeout, rdb, scheduler.New(1, 10), nil).handler()
+
+	do := func(target, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		rec := do("/v1/fibonacci?from=-2&to=2", MediaCSV)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+		require.Equal(t, "index,value\n-2,-1\n-1,1\n0,0\n1,1\n2,1\n", rec.Body.String())
+
+		rec = do("/v1/fibonacci?from=2&to=1", MediaCSV)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		require.Equal(t, "error\nparameter from (2) should not be greater than to (1)\n", rec.Body.String())
+	})
+
+	t.Run("text", func(t *testing.T) {
+		rec := do("/v1/fibonacci/7", MediaText)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "13\n", rec.Body.String())
+	})
+
+	t.Run("cbor", func(t *testing.T) {
+		rec := do("/v1/fibonacci?from=0&to=3", MediaCBOR)
+		require.Equal(t, MediaCBOR, rec.Header().Get("Content-Type"))
+
+		var resp RangeResponse
+		require.NoError(t, cbor.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Equal(t, RangeResponse{From: 0, To: 3, Values: []string{"0", "1", "1", "2"}}, resp)
+	})
+
+	t.Run("protobuf", func(t *testing.T) {
+		rec := do("/v1/fibonacci?from=0&to=3", MediaProtobuf)
+		require.Equal(t, MediaProtobuf, rec.Header().Get("Content-Type"))
+
+		var resp pb.Response
+		require.NoError(t, proto.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Equal(t, []string{"0", "1", "1", "2"}, resp.Data)
+	})
+
+	t.Run("not acceptable", func(t *testing.T) {
+		rec := do("/v1/fibonacci/1", "image/png")
+		require.Equal(t, http.StatusNotAcceptable, rec.Code)
+		require.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+	})
+}
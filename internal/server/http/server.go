@@ -1,56 +1,158 @@
 package httpserver
 
-
 import (
+	"context"
+	"crypto/tls"
 	"errors"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
+	"sync/atomic"
 	"time"
 
+	"github.com/dmitrykharchenko95/fibonacci/internal/auth"
+	"github.com/dmitrykharchenko95/fibonacci/internal/compute"
+	"github.com/dmitrykharchenko95/fibonacci/internal/listener"
+	"github.com/dmitrykharchenko95/fibonacci/internal/pagination"
 	"github.com/dmitrykharchenko95/fibonacci/internal/rds"
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
 )
 
 type Server struct {
-	srv *http.Server
-	rdb *rds.Client
-	addr string
-	timeout time.Duration
+	srv     *http.Server
+	rdb     *rds.Client
+	runner  *compute.Runner
+	guard   *auth.Guard
+	mws     []Middleware
+	routes  map[string]http.Handler
+	direct  map[string]http.Handler
+	addrs   []string
+	timeout atomic.Int64
+	maxPage atomic.Int64
 }
 
-// New создает новый объект типа Server, который будет прослушивать адрес host:httpPort. Аргумент timeout устанавливает
-// максимальное время работы функции service.GetFibonacci, вызываемой в хэндлере getFib.
-func New(host, port string, timeout time.Duration, rdb *rds.Client) *Server {
+// New создает новый объект типа Server, который будет прослушивать адреса addrs (форматы адресов описаны в
+// listener.Parse). При непустом tlsCfg сервер принимает только HTTPS соединения. Аргумент timeout устанавливает
+// максимальное время работы функции service.GetFibonacci, вызываемой в хэндлере getFib. Через sch ограничивается
+// количество одновременно выполняемых вычислений, guard проверяет права клиента на запрошенный диапазон (nil guard не
+// накладывает ограничений). Middleware из mws применяются к запросам в порядке передачи.
+func New(addrs []string, tlsCfg *tls.Config, timeout time.Duration, rdb *rds.Client, sch *scheduler.Scheduler, guard *auth.Guard,
+	mws ...Middleware) *Server {
 
-	addr := net.JoinHostPort(host, port)
-	return &Server{
+	s := &Server{
 		srv: &http.Server{
-			Addr: addr,
+			TLSConfig: tlsCfg,
 		},
-		rdb: rdb,
-		addr: addr,
-		timeout: timeout,
+		rdb:    rdb,
+		runner: compute.New(sch, guard),
+		guard:  guard,
+		mws:    mws,
+		routes: make(map[string]http.Handler),
+		direct: map[string]http.Handler{openAPIPath: OpenAPI()},
+		addrs:  addrs,
 	}
+	s.SetTimeout(timeout)
+	s.SetMaxPageSize(pagination.DefaultMaxSize)
+	return s
 }
 
-// Start запускает http сервер.
-func (s *Server) Start () error {
-	mux := http.NewServeMux()
-	mux.HandleFunc("/", s.getFib)
-	s.srv.Handler = mux
+// Timeout возвращает максимальное время вычисления чисел Фибоначчи в рамках одного запроса.
+func (s *Server) Timeout() time.Duration {
+	return time.Duration(s.timeout.Load())
+}
+
+// SetTimeout изменяет максимальное время вычисления. Может вызываться во время работы сервера.
+func (s *Server) SetTimeout(timeout time.Duration) {
+	s.timeout.Store(int64(timeout))
+}
+
+// MaxPageSize возвращает наибольшее количество чисел в одной странице диапазона.
+func (s *Server) MaxPageSize() int {
+	return int(s.maxPage.Load())
+}
+
+// SetMaxPageSize изменяет наибольший размер страницы диапазона. Может вызываться во время работы сервера.
+func (s *Server) SetMaxPageSize(size int) {
+	s.maxPage.Store(int64(size))
+}
+
+// Handle регистрирует дополнительный обработчик h для шаблона pattern. Должен вызываться до Start.
+func (s *Server) Handle(pattern string, h http.Handler) {
+	s.routes[pattern] = h
+}
+
+// HandleDirect регистрирует обработчик h для шаблона pattern, к которому не применяются Middleware сервера (например,
+// проверки здоровья, не требующие аутентификации). Должен вызываться до Start.
+func (s *Server) HandleDirect(pattern string, h http.Handler) {
+	s.direct[pattern] = h
+}
 
-	log.Printf("Start http server on %s...\n", s.addr)
-	if err := s.srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+// Start запускает http сервер на всех адресах. Если какой-либо адрес не удалось прослушивать, сервер не запускается.
+func (s *Server) Start() error {
+	lsns, err := listener.ListenAll(s.addrs)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	s.srv.Handler = s.handler()
+
+	// Serve дополняет TLSConfig для HTTP/2, поэтому режим сервера определяется до запуска.
+	useTLS := s.srv.TLSConfig != nil
+
+	return listener.ServeAll(lsns, func(l net.Listener) error {
+		var err error
+		if useTLS {
+			slog.Info("start https server", "network", l.Addr().Network(), "addr", l.Addr().String())
+			err = s.srv.ServeTLS(l, "", "")
+		} else {
+			slog.Info("start http server", "network", l.Addr().Network(), "addr", l.Addr().String())
+			err = s.srv.Serve(l)
+		}
+
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	})
 }
 
-// Stop останавливает http сервер.
-func (s *Server) Stop() error {
-	log.Printf("Stop http server...")
+// handler возвращает корневой обработчик запросов сервера.
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.getFib)
+	mux.HandleFunc(v1Prefix, notFoundV1)
+	mux.HandleFunc(fibonacciPath, s.deprecatedV1(s.getFibRange))
+	mux.HandleFunc(fibonacciPath+"/", s.deprecatedV1(s.getFibNumber))
+	mux.HandleFunc(batchPath, s.deprecatedV1(s.postFibBatch))
+	for pattern, h := range s.routes {
+		mux.Handle(pattern, h)
+	}
+
+	var h http.Handler = mux
+	for i := len(s.mws) - 1; i >= 0; i-- {
+		h = s.mws[i](h)
+	}
+
+	root := http.NewServeMux()
+	root.Handle("/", h)
+	for pattern, h := range s.direct {
+		root.Handle(pattern, h)
+		mux.Handle(pattern, h)
+	}
 
-	return s.srv.Close()
+	return instrument(mux, root)
 }
 
+// Stop прекращает прием новых запросов и дожидается завершения обрабатываемых. После отмены ctx оставшиеся
+// соединения закрываются.
+func (s *Server) Stop(ctx context.Context) error {
+	slog.Info("stop http server")
+
+	err := s.srv.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		slog.Warn("http server grace period expired, closing connections")
+		return s.srv.Close()
+	}
+
+	return err
+}
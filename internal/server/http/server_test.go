@@ -2,15 +2,20 @@ package httpserver
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"os/exec"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/dmitrykharchenko95/fibonacci/internal/rds"
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
 	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/require"
 )
@@ -33,17 +38,13 @@ var (
 )
 
 func TestServer(t *testing.T) {
-	rdb := &rds.Client{
-		Cl: redis.NewClient(&redis.Options{
-			Addr:     net.JoinHostPort(redisHost, redisPort),
-			Password: "",
-			DB:       0,
-		}),
-		Expiration: redisExpiration,
-		MaxErrors:  redisMaxErr,
-	}
+	rdb := rds.NewClient(redis.NewClient(&redis.Options{
+		Addr:     net.JoinHostPort(redisHost, redisPort),
+		Password: "",
+		DB:       0,
+	}), redisExpiration, redisMaxErr)
 
-	s := New(httpHost, httpPort, timeout, rdb)
+	s := New([]string{net.JoinHostPort(httpHost, httpPort)}, nil, timeout, rdb, scheduler.New(1, 10), nil)
 
 	go func() {
 		err := s.Start()
@@ -51,10 +52,18 @@ func TestServer(t *testing.T) {
 	}()
 
 	defer func() {
-		err := s.Stop()
+		err := s.Stop(context.Background())
 		require.NoError(t, err)
 	}()
 
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", net.JoinHostPort(httpHost, httpPort))
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
 	t.Run("0,10", func(t *testing.T) {
 		cmd := exec.Command("curl", "-X", "GET", "-i", "localhost:8080/", `-d`, "0,10")
 
@@ -158,8 +167,9 @@ func TestServer(t *testing.T) {
 		}
 
 		expectedResponse = Response{
-			Data: []string{},
-			Err:  ErrWrongArgs.Error(),
+			Data:  []string{},
+			Err:   ErrWrongArgs.Error(),
+			Error: &ErrorBody{Code: CodeInvalidArgument, Message: ErrWrongArgs.Error()},
 		}
 
 		err = json.Unmarshal(resBody, &actualResponse)
@@ -181,9 +191,11 @@ func TestServer(t *testing.T) {
 				break
 			}
 		}
+		msg := ErrWrongArgs.Error() + `: "test" is not an integer`
 		expectedResponse = Response{
-			Data: []string{},
-			Err:  "strconv.Atoi: parsing \"test\": invalid syntax",
+			Data:  []string{},
+			Err:   msg,
+			Error: &ErrorBody{Code: CodeInvalidArgument, Message: msg},
 		}
 
 		err = json.Unmarshal(resBody, &actualResponse)
@@ -211,9 +223,12 @@ func TestServer(t *testing.T) {
 			}
 		}
 
+		msg := "timeout exit: returned 0 values from 1"
 		expectedResponse = Response{
 			Data: []string{},
-			Err:  "timeout exit: returned 0 values from 1",
+			Err:  msg,
+			Error: &ErrorBody{Code: CodeDeadlineExceeded, Message: msg,
+				Details: &ErrorDetails{Progress: &Progress{Completed: 0, Expected: 1}}},
 		}
 
 		err = json.Unmarshal(resBody, &actualResponse)
@@ -222,3 +237,37 @@ func TestServer(t *testing.T) {
 		require.Equal(t, expectedResponse, actualResponse)
 	})
 }
+
+func TestLegacyMaxPageSize(t *testing.T) {
+	rdb := rds.NewClient(redis.NewClient(&redis.Options{}), redisExpiration, 0)
+	s := New(nil, nil, timeout, rdb, scheduler.New(1, 10), nil)
+	s.SetMaxPageSize(10)
+	h := s.handler()
+
+	for body, code := range map[string]int{
+		"0,9":  http.StatusOK,
+		"0,10": http.StatusBadRequest,
+		"-9223372036854775808,9223372036854775807": http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", strings.NewReader(body)))
+		require.Equal(t, code, rec.Code, body)
+	}
+}
+
+func TestLegacyBody(t *testing.T) {
+	rdb := rds.NewClient(redis.NewClient(&redis.Options{}), redisExpiration, 0)
+	h := New(nil, nil, timeout, rdb, scheduler.New(1, 10), nil).handler()
+
+	// Тело chunked запроса имеет неизвестную длину (ContentLength = -1).
+	req := httptest.NewRequest(http.MethodGet, "/", strings.NewReader("0,3"))
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"Data":["0","1","1","2"]`)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", strings.NewReader(strings.Repeat(" ", 2000)+"0,3")))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
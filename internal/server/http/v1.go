@@ -0,0 +1,289 @@
+package httpserver
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/pagination"
+	"github.com/dmitrykharchenko95/fibonacci/internal/service"
+)
+
+// v1Prefix - префикс путей версионированного API.
+const v1Prefix = "/v1/"
+
+// Пути эндпоинтов версионированного API.
+const (
+	fibonacciPath = v1Prefix + "fibonacci"
+	openAPIPath   = v1Prefix + "openapi.json"
+)
+
+// sunset - дата удаления эндпоинтов /v1 и устаревшего эндпоинта "/" в формате заголовка Sunset (RFC 8594). Их заменяют
+// маршруты HTTP/JSON шлюза с префиксом successorPrefix, поэтому дата передается только при подключенном шлюзе.
+const sunset = "Fri, 30 Apr 2027 00:00:00 GMT"
+
+// successorPrefix - префикс путей HTTP/JSON шлюза, заменяющих эндпоинты /v1.
+const successorPrefix = "/rpc"
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// NumberResponse - ответ GET /v1/fibonacci/{n}: число Фибоначчи Value с порядковым номером N.
+type NumberResponse struct {
+	N     int    `json:"n"`
+	Value string `json:"value"`
+}
+
+// RangeResponse - ответ GET /v1/fibonacci?from=&to=: страница диапазона с числами Фибоначчи Values с порядковыми
+// номерами от From до To включительно.
+type RangeResponse struct {
+	From   int      `json:"from"`
+	To     int      `json:"to"`
+	Values []string `json:"values"`
+	// NextPageToken - токен следующей страницы диапазона; пуст для последней страницы.
+	NextPageToken string `json:"next_page_token,omitempty"`
+	// Error - ошибка, из-за которой вычислена только часть чисел (ответ со статусом 206 Partial Content).
+	Error *ErrorBody `json:"error,omitempty"`
+}
+
+// OpenAPI возвращает обработчик, отдающий описание версионированного API в формате OpenAPI 3.
+func OpenAPI() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !allowGet(w, r) {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if _, err := w.Write(openAPISpec); err != nil {
+			slog.Error("response write error", "error", err)
+		}
+	})
+}
+
+// methodNotAllowed возвращает ошибку 405 Method Not Allowed для запроса r.
+func methodNotAllowed(r *http.Request) *Error {
+	return &Error{Status: http.StatusMethodNotAllowed, Code: CodeMethodNotAllowed,
+		Message: fmt.Sprintf("method %s not supported on uri %s", r.Method, r.URL.Path)}
+}
+
+// allowGet проверяет, что запрос r выполнен методом GET или HEAD, и в противном случае отвечает статусом 405 Method Not
+// Allowed.
+func allowGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+
+	w.Header().Set("Allow", "GET, HEAD")
+	WriteError(w, r, methodNotAllowed(r))
+	slog.WarnContext(r.Context(), "unsupported method", "client", clientName(r), "method", r.Method, "uri", r.URL.Path)
+	return false
+}
+
+// notFoundV1 отвечает на запросы к неизвестным путям версионированного API статусом 404 Not Found.
+func notFoundV1(w http.ResponseWriter, r *http.Request) {
+	WriteError(w, r, &Error{Status: http.StatusNotFound, Code: CodeNotFound,
+		Message: fmt.Sprintf("uri %s not found", r.URL.Path)})
+}
+
+// getFibNumber обрабатывает запросы GET /v1/fibonacci/{n} и отправляет клиенту NumberResponse с числом Фибоначчи с
+// порядковым номером n, а с параметром verbose=true - VerboseResponse.
+func (s *Server) getFibNumber(w http.ResponseWriter, r *http.Request) {
+	param := strings.TrimPrefix(r.URL.Path, fibonacciPath+"/")
+	if param == "" || strings.Contains(param, "/") {
+		notFoundV1(w, r)
+		return
+	}
+
+	if !allowGet(w, r) || !acceptable(w, r) {
+		return
+	}
+
+	start := time.Now()
+	verbose, err := isVerbose(r)
+	if err != nil {
+		WriteError(w, r, err)
+		slog.WarnContext(r.Context(), "wrong arguments", "client", clientName(r), "error", err)
+		return
+	}
+
+	n, err := parseIndex("n", param)
+	if err != nil {
+		WriteError(w, r, err)
+		slog.WarnContext(r.Context(), "wrong arguments", "client", clientName(r), "error", err)
+		return
+	}
+
+	items, err := s.compute(r, n, n)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	var body interface{} = &NumberResponse{N: n, Value: items[0].Value}
+	if verbose {
+		body = newVerbose(items, start, "", nil)
+	}
+
+	writeBody(w, r, http.StatusOK, body)
+	slog.InfoContext(r.Context(), "sent fibonacci numbers", "client", clientName(r), "count", len(items))
+}
+
+// getFibRange обрабатывает запросы GET /v1/fibonacci?from=&to= и отправляет клиенту RangeResponse со страницей чисел
+// Фибоначчи с порядковыми номерами от from до to включительно. Размер страницы задается параметром page_size и
+// ограничивается MaxPageSize, следующая страница запрашивается с параметром page_token из ответа и указывается также в
+// заголовке Link. Если до истечения времени вычислена только часть чисел страницы, они передаются со статусом
+// 206 Partial Content и описанием ошибки, а следующая страница начинается с первого невычисленного числа. С параметром
+// verbose=true отправляется VerboseResponse. Запросы множеств номеров передаются getFibIndices.
+func (s *Server) getFibRange(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) || !acceptable(w, r) {
+		return
+	}
+
+	if isIndexSet(r) {
+		s.getFibIndices(w, r)
+		return
+	}
+
+	start := time.Now()
+	verbose, err := isVerbose(r)
+	if err != nil {
+		WriteError(w, r, err)
+		slog.WarnContext(r.Context(), "wrong arguments", "client", clientName(r), "error", err)
+		return
+	}
+
+	page, err := s.parsePage(r)
+	if err != nil {
+		WriteError(w, r, err)
+		slog.WarnContext(r.Context(), "wrong arguments", "client", clientName(r), "error", err)
+		return
+	}
+
+	items, err := s.compute(r, page.From, page.To)
+	resp, status := &RangeResponse{From: page.From, To: page.To, Values: service.Values(items)}, http.StatusOK
+	if err != nil {
+		e := toError(err)
+		if e.Status != http.StatusPartialContent {
+			WriteError(w, r, e)
+			return
+		}
+		resp.Error, status = e.body(), e.Status
+	}
+
+	if resp.NextPageToken = page.Next(len(items)); resp.NextPageToken != "" {
+		q := r.URL.Query()
+		q.Set("page_token", resp.NextPageToken)
+		w.Header().Add("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, q.Encode()))
+	}
+
+	var body interface{} = resp
+	if verbose {
+		body = newVerbose(items, start, resp.NextPageToken, resp.Error)
+	}
+
+	writeBody(w, r, status, body)
+	slog.InfoContext(r.Context(), "sent fibonacci numbers", "client", clientName(r), "count", len(items))
+}
+
+// parseRange возвращает значения обязательных параметров запроса from и to. Значение from не может превышать to.
+func parseRange(r *http.Request) (int, int, error) {
+	q := r.URL.Query()
+
+	from, err := parseIndex("from", q.Get("from"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	to, err := parseIndex("to", q.Get("to"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if from > to {
+		return 0, 0, invalidArgument("from", "parameter from (%d) should not be greater than to (%d)", from, to)
+	}
+
+	return from, to, nil
+}
+
+// parsePage возвращает страницу диапазона из параметров запроса from, to, page_size и page_token.
+func (s *Server) parsePage(r *http.Request) (pagination.Page, error) {
+	from, to, err := parseRange(r)
+	if err != nil {
+		return pagination.Page{}, err
+	}
+
+	q, size := r.URL.Query(), 0
+	if q.Has("page_size") {
+		if size, err = parseIndex("page_size", q.Get("page_size")); err != nil {
+			return pagination.Page{}, err
+		}
+		if size < 0 {
+			return pagination.Page{}, invalidArgument("page_size", "parameter page_size should not be negative")
+		}
+	}
+
+	page, err := pagination.Paginate(from, to, pagination.Size(size, s.MaxPageSize()), q.Get("page_token"))
+	if err != nil {
+		return pagination.Page{}, invalidArgument("page_token", "parameter page_token is invalid: %v", err)
+	}
+
+	return page, nil
+}
+
+// parseIndex разбирает значение val параметра name - порядковый номер числа Фибоначчи.
+func parseIndex(name, val string) (int, error) {
+	if val == "" {
+		return 0, invalidArgument(name, "parameter %s is required", name)
+	}
+
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, invalidArgument(name, "parameter %s is out of range", name)
+		}
+		return 0, invalidArgument(name, "parameter %s should be an integer, got %q", name, val)
+	}
+
+	return n, nil
+}
+
+// deprecate помечает ответ w устаревшим: добавляет заголовки Deprecation, Sunset и Link на замену successor.
+func deprecate(w http.ResponseWriter, successor string) {
+	w.Header().Set("Deprecation", "true")
+	w.Header().Set("Sunset", sunset)
+	w.Header().Add("Link", "<"+successor+">; rel=\"successor-version\"")
+}
+
+// deprecatedV1 возвращает обработчик, помечающий ответы next устаревшими с заменой на маршрут HTTP/JSON шлюза (см.
+// successor). Без подключенного шлюза замены нет, и next возвращается как есть.
+func (s *Server) deprecatedV1(next http.HandlerFunc) http.HandlerFunc {
+	if !s.hasGateway() {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		deprecate(w, successor(r))
+		next(w, r)
+	}
+}
+
+// successor возвращает путь HTTP/JSON шлюза, заменяющий запрос r к эндпоинтам /v1: множества номеров запрашиваются
+// через /rpc/v1/indices, остальные запросы - по тому же пути с префиксом successorPrefix.
+func successor(r *http.Request) string {
+	if r.URL.Path == fibonacciPath && isIndexSet(r) {
+		return successorPrefix + v1Prefix + "indices"
+	}
+	return successorPrefix + r.URL.Path
+}
+
+// hasGateway сообщает, подключен ли к серверу HTTP/JSON шлюз (обработчик для префикса successorPrefix).
+func (s *Server) hasGateway() bool {
+	_, ok := s.routes[successorPrefix+"/"]
+	return ok
+}
@@ -0,0 +1,180 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/rds"
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func TestV1(t *testing.T) {
+	rdb := rds.NewClient(redis.NewClient(&redis.Options{}), redisExpiration, 0)
+	h := New(nil, nil, timeout, rdb, scheduler.New(1, 10), nil).handler()
+
+	do := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	requireError := func(rec *httptest.ResponseRecorder, status int, code string) {
+		t.Helper()
+		require.Equal(t, status, rec.Code)
+
+		var resp ErrorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Equal(t, code, resp.Error.Code)
+		require.NotEmpty(t, resp.Error.Message)
+	}
+
+	t.Run("number", func(t *testing.T) {
+		rec := do(http.MethodGet, "/v1/fibonacci/10")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp NumberResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Equal(t, NumberResponse{N: 10, Value: "55"}, resp)
+
+		rec = do(http.MethodGet, "/v1/fibonacci/-6")
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Equal(t, NumberResponse{N: -6, Value: "-8"}, resp)
+	})
+
+	t.Run("range", func(t *testing.T) {
+		rec := do(http.MethodGet, "/v1/fibonacci?from=0&to=6")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp RangeResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Equal(t, RangeResponse{From: 0, To: 6, Values: []string{"0", "1", "1", "2", "3", "5", "8"}}, resp)
+	})
+
+	t.Run("pages", func(t *testing.T) {
+		var (
+			values []string
+			token  string
+		)
+		for pages := 0; pages < 3; pages++ {
+			rec := do(http.MethodGet, "/v1/fibonacci?from=0&to=6&page_size=3&page_token="+token)
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var resp RangeResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			require.Equal(t, 3*pages, resp.From)
+			values, token = append(values, resp.Values...), resp.NextPageToken
+
+			links := strings.Join(rec.Header().Values("Link"), ", ")
+			if token == "" {
+				require.NotContains(t, links, `rel="next"`)
+				break
+			}
+			require.Contains(t, links, "page_token="+token)
+		}
+		require.Equal(t, []string{"0", "1", "1", "2", "3", "5", "8"}, values)
+		require.Empty(t, token)
+
+		rec := do(http.MethodGet, "/v1/fibonacci?from=0&to=6&page_size=3")
+		var resp RangeResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		requireError(do(http.MethodGet, "/v1/fibonacci?from=0&to=7&page_token="+resp.NextPageToken),
+			http.StatusBadRequest, "invalid_argument")
+		requireError(do(http.MethodGet, "/v1/fibonacci?from=0&to=6&page_token=abc"), http.StatusBadRequest,
+			"invalid_argument")
+		requireError(do(http.MethodGet, "/v1/fibonacci?from=0&to=6&page_size=-1"), http.StatusBadRequest,
+			"invalid_argument")
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		requireError(do(http.MethodGet, "/v1/fibonacci/ten"), http.StatusBadRequest, "invalid_argument")
+		requireError(do(http.MethodGet, "/v1/fibonacci/99999999999999999999"), http.StatusBadRequest, "invalid_argument")
+		requireError(do(http.MethodGet, "/v1/fibonacci?from=1"), http.StatusBadRequest, "invalid_argument")
+		requireError(do(http.MethodGet, "/v1/fibonacci?from=a&to=2"), http.StatusBadRequest, "invalid_argument")
+		requireError(do(http.MethodGet, "/v1/fibonacci?from=5&to=2"), http.StatusBadRequest, "invalid_argument")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		requireError(do(http.MethodGet, "/v1/fibonacci/1/2"), http.StatusNotFound, "not_found")
+		requireError(do(http.MethodGet, "/v1/unknown"), http.StatusNotFound, "not_found")
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		rec := do(http.MethodPost, "/v1/fibonacci/1")
+		requireError(rec, http.StatusMethodNotAllowed, "method_not_allowed")
+		require.Equal(t, "GET, HEAD", rec.Header().Get("Allow"))
+	})
+
+	t.Run("openapi", func(t *testing.T) {
+		rec := do(http.MethodGet, "/v1/openapi.json")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var spec struct {
+			OpenAPI string
+			Paths   map[string]interface{}
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &spec))
+		require.Equal(t, "3.0.3", spec.OpenAPI)
+		require.Contains(t, spec.Paths, "/v1/fibonacci/{n}")
+		require.Contains(t, spec.Paths, "/v1/fibonacci")
+	})
+
+	t.Run("deprecated root", func(t *testing.T) {
+		rec := do(http.MethodPost, "/")
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		require.Equal(t, "true", rec.Header().Get("Deprecation"))
+		require.Contains(t, rec.Header().Get("Link"), "</v1/fibonacci>")
+		require.Empty(t, rec.Header().Get("Sunset"))
+
+		rec = do(http.MethodGet, "/v1/fibonacci/10")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Empty(t, rec.Header().Get("Deprecation"))
+		require.Empty(t, rec.Header().Get("Link"))
+	})
+}
+
+func TestV1Deprecated(t *testing.T) {
+	rdb := rds.NewClient(redis.NewClient(&redis.Options{}), redisExpiration, 0)
+	s := New(nil, nil, timeout, rdb, scheduler.New(1, 10), nil)
+	s.Handle("/rpc/", http.NotFoundHandler())
+	h := s.handler()
+
+	do := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	t.Run("root", func(t *testing.T) {
+		rec := do(http.MethodPost, "/")
+		require.Equal(t, "true", rec.Header().Get("Deprecation"))
+		require.Equal(t, sunset, rec.Header().Get("Sunset"))
+		require.Contains(t, rec.Header().Get("Link"), "</rpc/v1/fibonacci>")
+	})
+
+	t.Run("v1", func(t *testing.T) {
+		rec := do(http.MethodGet, "/v1/fibonacci/10")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "true", rec.Header().Get("Deprecation"))
+		require.Equal(t, sunset, rec.Header().Get("Sunset"))
+		require.Equal(t, `</rpc/v1/fibonacci/10>; rel="successor-version"`, rec.Header().Get("Link"))
+
+		rec = do(http.MethodGet, "/v1/fibonacci?from=0&to=20000")
+		links := rec.Header().Values("Link")
+		require.Len(t, links, 2)
+		require.Equal(t, `</rpc/v1/fibonacci>; rel="successor-version"`, links[0])
+		require.Contains(t, links[1], `rel="next"`)
+
+		rec = do(http.MethodGet, "/v1/fibonacci?indices=1,2")
+		require.Equal(t, `</rpc/v1/indices>; rel="successor-version"`, rec.Header().Get("Link"))
+		rec = do(http.MethodGet, "/v1/fibonacci?from=0&to=10&step=5")
+		require.Equal(t, `</rpc/v1/indices>; rel="successor-version"`, rec.Header().Get("Link"))
+
+		rec = do(http.MethodGet, "/v1/openapi.json")
+		require.Empty(t, rec.Header().Get("Deprecation"))
+	})
+}
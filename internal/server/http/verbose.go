@@ -0,0 +1,102 @@
+package httpserver
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/server/grpc/pb"
+	"github.com/dmitrykharchenko95/fibonacci/internal/service"
+)
+
+// VerboseResponse - подробный ответ GET /v1/fibonacci/{n} и GET /v1/fibonacci с параметром verbose=true: числа
+// Фибоначчи со сведениями об их получении и итоги запроса.
+type VerboseResponse struct {
+	Items []Item `json:"items"`
+	Stats Stats  `json:"stats"`
+	// NextPageToken - токен следующей страницы диапазона, как в RangeResponse.
+	NextPageToken string `json:"next_page_token,omitempty"`
+	// Error - ошибка, из-за которой вычислена только часть чисел (ответ со статусом 206 Partial Content).
+	Error *ErrorBody `json:"error,omitempty"`
+}
+
+// Item - число Фибоначчи подробного ответа: порядковый номер, значение, количество десятичных цифр без учета знака,
+// источник значения (computed или redis) и время его получения в микросекундах.
+type Item struct {
+	Index         int    `json:"index"`
+	Value         string `json:"value"`
+	Digits        int    `json:"digits"`
+	Source        string `json:"source"`
+	ComputeTimeUs int64  `json:"compute_time_us"`
+}
+
+// Stats - итоги запроса: количество чисел в ответе, количество чисел, полученных из кэша, и время обработки запроса в
+// микросекундах.
+type Stats struct {
+	Count     int   `json:"count"`
+	CacheHits int   `json:"cache_hits"`
+	ElapsedUs int64 `json:"elapsed_us"`
+}
+
+// isVerbose возвращает значение параметра запроса verbose. Отсутствующий параметр означает краткий ответ.
+func isVerbose(r *http.Request) (bool, error) {
+	val := r.URL.Query().Get("verbose")
+	if val == "" {
+		return false, nil
+	}
+
+	verbose, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, invalidArgument("verbose", "parameter verbose should be a boolean, got %q", val)
+	}
+	return verbose, nil
+}
+
+// newVerbose возвращает подробный ответ с числами items для запроса, обработка которого началась в start. Токен
+// следующей страницы next и ошибка e переносятся из краткого ответа.
+func newVerbose(items []service.Item, start time.Time, next string, e *ErrorBody) *VerboseResponse {
+	return &VerboseResponse{Items: newItems(items), Stats: newStats(items, start), NextPageToken: next, Error: e}
+}
+
+// newItems возвращает числа items подробного ответа.
+func newItems(items []service.Item) []Item {
+	res := make([]Item, len(items))
+	for i, it := range items {
+		res[i] = Item{Index: it.Index, Value: it.Value, Digits: it.Digits(), Source: string(it.Source),
+			ComputeTimeUs: it.Duration.Microseconds()}
+	}
+	return res
+}
+
+// newStats возвращает итоги запроса с числами items, обработка которого началась в start.
+func newStats(items []service.Item, start time.Time) Stats {
+	return Stats{
+		Count:     len(items),
+		CacheHits: service.CacheHits(items),
+		ElapsedUs: time.Since(start).Microseconds(),
+	}
+}
+
+// records возвращает строки CSV со сведениями о каждом числе.
+func (r *VerboseResponse) records() [][]string {
+	recs := [][]string{{"index", "value", "digits", "source", "compute_time_us"}}
+	for _, it := range r.Items {
+		recs = append(recs, []string{strconv.Itoa(it.Index), it.Value, strconv.Itoa(it.Digits), it.Source,
+			strconv.FormatInt(it.ComputeTimeUs, 10)})
+	}
+	return recs
+}
+
+// rows возвращает значения для текстового формата; сведения о числах передаются только в CSV через records.
+func (r *VerboseResponse) rows() (int, []string) {
+	values := make([]string, len(r.Items))
+	for i, it := range r.Items {
+		values[i] = it.Value
+	}
+	return 0, values
+}
+
+func (r *VerboseResponse) message() *pb.Response {
+	_, values := r.rows()
+	return &pb.Response{Data: values}
+}
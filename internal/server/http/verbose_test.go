@@ -0,0 +1,79 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/rds"
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerbose(t *testing.T) {
+	rdb := rds.NewClient(redis.NewClient(&redis.Options{}), redisExpiration, 0)
+	h := New(nil, nil, timeout, rdb, scheduler.New(1, 10), nil).handler()
+
+	get := func(target, accept string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept", accept)
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	decode := func(rec *httptest.ResponseRecorder) VerboseResponse {
+		t.Helper()
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp VerboseResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return resp
+	}
+
+	t.Run("range", func(t *testing.T) {
+		resp := decode(get("/v1/fibonacci?from=10&to=12&page_size=2&verbose=true", ""))
+		require.Len(t, resp.Items, 2)
+		require.Equal(t, 2, resp.Stats.Count)
+		require.Zero(t, resp.Stats.CacheHits)
+		require.NotEmpty(t, resp.NextPageToken)
+
+		it := resp.Items[1]
+		require.Equal(t, 11, it.Index)
+		require.Equal(t, "89", it.Value)
+		require.Equal(t, 2, it.Digits)
+		require.Equal(t, "computed", it.Source)
+	})
+
+	t.Run("number and indices", func(t *testing.T) {
+		resp := decode(get("/v1/fibonacci/-6?verbose=1", ""))
+		require.Equal(t, []Item{{Index: -6, Value: "-8", Digits: 1, Source: "computed",
+			ComputeTimeUs: resp.Items[0].ComputeTimeUs}}, resp.Items)
+
+		resp = decode(get("/v1/fibonacci?indices=20,3&verbose=true", ""))
+		require.Equal(t, 20, resp.Items[0].Index)
+		require.Equal(t, "6765", resp.Items[0].Value)
+		require.Equal(t, 3, resp.Items[1].Index)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		rec := get("/v1/fibonacci?from=5&to=6&verbose=true", MediaCSV)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		require.Equal(t, "index,value,digits,source,compute_time_us", lines[0])
+		require.True(t, strings.HasPrefix(lines[2], "6,8,1,computed,"), lines[2])
+	})
+
+	t.Run("not verbose", func(t *testing.T) {
+		rec := get("/v1/fibonacci?from=0&to=1&verbose=false", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NotContains(t, rec.Body.String(), "items")
+
+		rec = get("/v1/fibonacci/1?verbose=maybe", "")
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
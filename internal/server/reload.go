@@ -0,0 +1,84 @@
+package server
+
+import (
+	"log/slog"
+	"reflect"
+
+	"github.com/dmitrykharchenko95/fibonacci/config"
+	"github.com/dmitrykharchenko95/fibonacci/internal/logger"
+)
+
+// liveFields - параметры конфигурации, изменения которых Reload применяет без перезапуска сервиса.
+var liveFields = map[string]bool{
+	"HTTP.Timeout":        true,
+	"HTTP.MaxPageSize":    true,
+	"GRPC.Timeout":        true,
+	"GRPC.MaxPageSize":    true,
+	"Redis.Expiration":    true,
+	"Redis.MaxErrors":     true,
+	"RateLimit.Rate":      true,
+	"RateLimit.Burst":     true,
+	"RateLimit.Endpoints": true,
+	"Quota.Default":       true,
+	"Quota.Clients":       true,
+	"Log.Level":           true,
+}
+
+// Reload применяет изменения конфигурации cfg, не требующие перезапуска: таймауты вычислений, размеры страниц
+// диапазонов, время хранения значений и допустимое количество ошибок Redis, лимиты частоты запросов, квоты и уровень
+// логирования. Reload возвращает список остальных измененных параметров (например, адресов серверов), которые вступят
+// в силу только после перезапуска. Если конфигурация не проходит проверку, не применяется ни одно изменение.
+func (s *Sever) Reload(cfg *config.Config) ([]string, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := logger.SetLevel(cfg.Log.Level); err != nil {
+		return nil, err
+	}
+	if s.http != nil {
+		s.http.SetTimeout(cfg.HTTP.Timeout)
+		s.http.SetMaxPageSize(cfg.HTTP.MaxPageSize)
+	}
+	if s.grpc != nil {
+		s.grpc.SetTimeout(cfg.GRPC.Timeout)
+		s.grpc.SetMaxPageSize(cfg.GRPC.MaxPageSize)
+	}
+	s.rdb.SetExpiration(cfg.Redis.Expiration)
+	s.rdb.SetMaxErrors(cfg.Redis.MaxErrors)
+	if s.limiter != nil {
+		s.limiter.SetLimits(rateLimits(cfg.RateLimit))
+	}
+	if s.quota != nil {
+		s.quota.SetLimits(quotaLimits(cfg.Quota))
+	}
+
+	slog.Info("config reloaded", "http_timeout", cfg.HTTP.Timeout, "grpc_timeout", cfg.GRPC.Timeout,
+		"redis_expiration", cfg.Redis.Expiration, "redis_max_errors", cfg.Redis.MaxErrors, "log_level", logger.Level())
+
+	var restart []string
+	changedFields("", reflect.ValueOf(*s.cfg), reflect.ValueOf(*cfg), &restart)
+	return restart, nil
+}
+
+// changedFields добавляет в out имена параметров, значения которых различаются в a и b, кроме liveFields.
+func changedFields(prefix string, a, b reflect.Value, out *[]string) {
+	for i := 0; i < a.NumField(); i++ {
+		name := a.Type().Field(i).Name
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		if liveFields[name] {
+			continue
+		}
+
+		fa, fb := a.Field(i), b.Field(i)
+		if fa.Kind() == reflect.Struct {
+			changedFields(name, fa, fb, out)
+			continue
+		}
+		if !reflect.DeepEqual(fa.Interface(), fb.Interface()) {
+			*out = append(*out, name)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/config"
+	"github.com/dmitrykharchenko95/fibonacci/internal/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	cfg, err := config.New("../../configs/fibonacci_config.json", nil)
+	require.NoError(t, err)
+
+	s, err := New(cfg)
+	require.NoError(t, err)
+	defer logger.SetLevel("info")
+
+	next := *cfg
+	next.HTTP.Timeout = 5 * time.Second
+	next.GRPC.Port = "50053"
+	next.Redis.Expiration = time.Hour
+	next.Redis.MaxErrors = 3
+	next.Log.Level = "debug"
+
+	restart, err := s.Reload(&next)
+	require.NoError(t, err)
+	require.Equal(t, []string{"GRPC.Port"}, restart)
+	require.Equal(t, 5*time.Second, s.http.Timeout())
+	require.Equal(t, time.Hour, s.rdb.Expiration())
+	require.Equal(t, 3, s.rdb.MaxErrors())
+	require.Equal(t, "debug", logger.Level())
+
+	t.Run("invalid config is rejected", func(t *testing.T) {
+		bad := next
+		bad.HTTP.Timeout = time.Minute
+		bad.GRPC.Timeout = -time.Second
+		bad.Log.Level = "verbose"
+
+		_, err := s.Reload(&bad)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "GRPC.Timeout")
+		require.Contains(t, err.Error(), "Log.Level")
+		require.Equal(t, 5*time.Second, s.http.Timeout())
+		require.Equal(t, "debug", logger.Level())
+	})
+
+	t.Run("grpc only", func(t *testing.T) {
+		grpcOnly := *cfg
+		grpcOnly.HTTP.Enabled = false
+		grpcOnly.Metrics.Enabled = false
+		grpcOnly.HTTP.Gateway = false
+		grpcOnly.GRPC.Web = false
+
+		s, err := New(&grpcOnly)
+		require.NoError(t, err)
+		require.Nil(t, s.http)
+
+		next := grpcOnly
+		next.HTTP.Timeout = 5 * time.Second
+		next.GRPC.Listen = []string{"unix:///tmp/fibonacci.sock"}
+
+		restart, err := s.Reload(&next)
+		require.NoError(t, err)
+		require.Equal(t, []string{"GRPC.Listen"}, restart)
+	})
+}
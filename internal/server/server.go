@@ -1,77 +1,455 @@
 package server
 
 import (
-	"log"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
 	"sync"
 	"time"
 
 	"github.com/dmitrykharchenko95/fibonacci/config"
+	"github.com/dmitrykharchenko95/fibonacci/internal/auth"
+	"github.com/dmitrykharchenko95/fibonacci/internal/health"
+	"github.com/dmitrykharchenko95/fibonacci/internal/metrics"
+	"github.com/dmitrykharchenko95/fibonacci/internal/quota"
+	"github.com/dmitrykharchenko95/fibonacci/internal/ratelimit"
 	"github.com/dmitrykharchenko95/fibonacci/internal/rds"
+	"github.com/dmitrykharchenko95/fibonacci/internal/scheduler"
+	adminserver "github.com/dmitrykharchenko95/fibonacci/internal/server/admin"
 	grpcserver "github.com/dmitrykharchenko95/fibonacci/internal/server/grpc"
 	httpserver "github.com/dmitrykharchenko95/fibonacci/internal/server/http"
+	"github.com/dmitrykharchenko95/fibonacci/internal/service"
+	"github.com/dmitrykharchenko95/fibonacci/internal/tlsconfig"
+	"github.com/dmitrykharchenko95/fibonacci/internal/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
-const (
-	defaultTimeout = 10 * time.Second
-)
+const tracingShutdownTimeout = 5 * time.Second
+
+var errRedisDisabled = errors.New("redis disabled after errors")
 
 type Sever struct {
-	http *httpserver.Server
-	grpc *grpcserver.Server
+	cfg             *config.Config
+	http            *httpserver.Server
+	grpc            *grpcserver.Server
+	admin           *adminserver.Server
+	limiter         *ratelimit.Limiter
+	quota           *quota.Manager
+	health          *health.Checker
+	rdb             *rds.Client
+	warmUpIndex     int
+	warmUpTimeout   time.Duration
+	drainDelay      time.Duration
+	gracePeriod     time.Duration
+	shutdownTracing func(context.Context) error
+
+	stopOnce sync.Once
+	stopped  chan struct{}
 }
 
-func New(cfg *config.Config) *Sever {
-	httpTimeout, err := time.ParseDuration(cfg.HTTP.Timeout)
-	if err != nil {
-		log.Printf("parse grpc timeout fail: %v", err)
-		log.Printf("use default value - %v", defaultTimeout)
-		httpTimeout = defaultTimeout
+func New(cfg *config.Config) (*Sever, error) {
+	workers := cfg.Scheduler.Workers
+	if workers == 0 {
+		workers = runtime.NumCPU()
+		slog.Info("scheduler workers not set, use default value", "default", workers)
 	}
 
-	grpcTimeout, err := time.ParseDuration(cfg.GRPC.Timeout)
-	if err != nil {
-		log.Printf("parse grpc timeout fail: %v", err)
-		log.Printf("use default value - %v", defaultTimeout)
-		grpcTimeout = defaultTimeout
+	rdb := rds.NewRedisClient(cfg.Redis)
+	sch := scheduler.New(workers, cfg.Scheduler.QueueSize)
+
+	var (
+		httpMws  = []httpserver.Middleware{httpserver.RequestID(), httpserver.Tracing()}
+		unaryInt = []grpc.UnaryServerInterceptor{
+			grpcserver.RequestIDUnaryInterceptor(),
+			grpcserver.MetricsUnaryInterceptor(),
+			grpcserver.TracingUnaryInterceptor(),
+		}
+		streamInt = []grpc.StreamServerInterceptor{
+			grpcserver.RequestIDStreamInterceptor(),
+			grpcserver.MetricsStreamInterceptor(),
+			grpcserver.TracingStreamInterceptor(),
+		}
+	)
+
+	var (
+		shutdownTracing func(context.Context) error
+		err             error
+	)
+	if cfg.Tracing.Enabled {
+		shutdownTracing, err = tracing.Init(context.Background(), cfg.Tracing.Endpoint, cfg.Tracing.Insecure,
+			cfg.Tracing.ServiceName, cfg.Tracing.SampleRatio)
+		if err != nil {
+			return nil, fmt.Errorf("tracing: %w", err)
+		}
 	}
 
-	rdb := rds.NewRedisClient(cfg.Redis)
+	// Лимитер создается до аутентификации: в нем учитываются и неудачные попытки аутентификации.
+	var lim *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		lim = newRateLimiter(cfg.RateLimit, rdb)
+	}
+
+	var guard *auth.Guard
+	if cfg.Auth.Enabled {
+		guard, err = newGuard(cfg.Auth)
+		if err != nil {
+			return nil, err
+		}
+
+		httpMws = append(httpMws, httpserver.Authenticate(guard, cfg.Auth.APIKeyHeader, lim))
+		unaryInt = append(unaryInt, grpcserver.AuthUnaryInterceptor(guard, cfg.Auth.APIKeyHeader, lim))
+		streamInt = append(streamInt, grpcserver.AuthStreamInterceptor(guard, cfg.Auth.APIKeyHeader, lim))
+	}
+
+	if lim != nil {
+		httpMws = append(httpMws, httpserver.RateLimit(lim, cfg.RateLimit.KeyHeader, guard))
+		unaryInt = append(unaryInt, grpcserver.RateLimitUnaryInterceptor(lim, cfg.RateLimit.KeyHeader, guard))
+		streamInt = append(streamInt, grpcserver.RateLimitStreamInterceptor(lim, cfg.RateLimit.KeyHeader, guard))
+	}
+
+	var qm *quota.Manager
+	if cfg.Quota.Enabled {
+		qm, err = newQuotaManager(cfg.Quota, rdb)
+		if err != nil {
+			return nil, err
+		}
+
+		httpMws = append(httpMws, httpserver.Quota(qm))
+		unaryInt = append(unaryInt, grpcserver.QuotaUnaryInterceptor(qm))
+	}
+
+	hc := newHealthChecker(cfg.Health, rdb)
+	timeouters := make(map[string]adminserver.Timeouter)
+
+	var httpSrv *httpserver.Server
+	if cfg.HTTP.Enabled {
+		httpTLS, err := newTLSConfig(cfg.HTTP.CertFile, cfg.HTTP.KeyFile, cfg.HTTP.CAFile, cfg.HTTP.ClientCertRequired,
+			"h2", "http/1.1")
+		if err != nil {
+			return nil, err
+		}
+
+		httpSrv = httpserver.New(cfg.HTTP.Addrs(), httpTLS, cfg.HTTP.Timeout, rdb, sch, guard, httpMws...)
+		httpSrv.SetMaxPageSize(cfg.HTTP.MaxPageSize)
+
+		if cfg.Metrics.Enabled {
+			httpSrv.Handle(cfg.Metrics.Path, metrics.Handler())
+		}
+
+		httpSrv.HandleDirect(cfg.Health.LivenessPath, httpserver.Liveness())
+		httpSrv.HandleDirect(cfg.Health.ReadinessPath, httpserver.Readiness(hc))
+		timeouters["http"] = httpSrv
+	}
+
+	var grpcSrv *grpcserver.Server
+	if cfg.GRPC.Enabled {
+		grpcOpts := []grpc.ServerOption{
+			grpc.ChainUnaryInterceptor(unaryInt...),
+			grpc.ChainStreamInterceptor(streamInt...),
+		}
+
+		grpcTLS, err := newTLSConfig(cfg.GRPC.CertFile, cfg.GRPC.KeyFile, cfg.GRPC.CAFile, cfg.GRPC.ClientCertRequired,
+			"h2")
+		if err != nil {
+			return nil, err
+		}
+		if grpcTLS != nil {
+			grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(grpcTLS)))
+		}
+
+		grpcSrv = grpcserver.New(cfg.GRPC.Addrs(), cfg.GRPC.Timeout, rdb, sch, guard, grpcOpts...)
+		grpcSrv.SetMaxPageSize(cfg.GRPC.MaxPageSize)
+		grpcSrv.RegisterHealth(hc)
+		if cfg.GRPC.Reflection {
+			grpcSrv.RegisterReflection()
+		}
+		timeouters["grpc"] = grpcSrv
+	}
+
+	if cfg.HTTP.Gateway && httpSrv != nil && grpcSrv != nil {
+		gw, err := grpcSrv.Gateway(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("gateway: %w", err)
+		}
+		httpSrv.Handle(grpcserver.GatewayPrefix, gw)
+	}
+
+	if cfg.GRPC.Web && httpSrv != nil && grpcSrv != nil {
+		web := grpcSrv.GRPCWeb(cfg.GRPC.WebOrigins)
+		for _, pattern := range grpcSrv.Paths() {
+			httpSrv.HandleDirect(pattern, web)
+		}
+	}
+
+	var adminSrv *adminserver.Server
+	if cfg.Admin.Enabled {
+		adminMws := []httpserver.Middleware{httpserver.RequestID()}
+		if guard != nil {
+			adminMws = append(adminMws, httpserver.Authenticate(guard, cfg.Auth.APIKeyHeader, lim),
+				httpserver.RequireScope(auth.ScopeAdmin))
+		}
+
+		adminSrv = adminserver.New(cfg.Admin.Host, cfg.Admin.Port, timeouters, adminMws...)
+	}
 
 	return &Sever{
-		http: httpserver.New(cfg.HTTP.Host, cfg.HTTP.Port, httpTimeout, rdb),
-		grpc: grpcserver.New(cfg.GRPC.Host, cfg.GRPC.Port, grpcTimeout, rdb),
+		cfg:             cfg,
+		http:            httpSrv,
+		grpc:            grpcSrv,
+		admin:           adminSrv,
+		limiter:         lim,
+		quota:           qm,
+		health:          hc,
+		rdb:             rdb,
+		warmUpIndex:     cfg.Health.WarmUpIndex,
+		warmUpTimeout:   cfg.HTTP.Timeout,
+		drainDelay:      cfg.Shutdown.DrainDelay,
+		gracePeriod:     cfg.Shutdown.GracePeriod,
+		stopped:         make(chan struct{}),
+		shutdownTracing: shutdownTracing,
+	}, nil
+}
+
+// newHealthChecker создает health.Checker по конфигурации cfg. Проверки Redis добавляются, только если Redis
+// используется для кэширования.
+func newHealthChecker(cfg config.HealthConfig, rdb *rds.Client) *health.Checker {
+	hc := health.New(cfg.CheckTimeout)
+	if cfg.RedisCheck && service.UseRedis() && rdb.MaxErrors() != 0 {
+		hc.Add("redis", cfg.RequireRedis, func(ctx context.Context) error {
+			return rdb.Cl.Ping(ctx).Err()
+		})
+		hc.Add("redis_circuit", cfg.RequireRedis, func(ctx context.Context) error {
+			if !service.UseRedis() {
+				return errRedisDisabled
+			}
+			return nil
+		})
 	}
+
+	return hc
 }
 
-func (s *Sever) Start() {
-	var wg sync.WaitGroup
+// warmUp вычисляет числа с порядковыми номерами до warmUpIndex, заполняя кэш Redis, и отмечает сервис готовым.
+func (s *Sever) warmUp() {
+	if s.warmUpIndex > 0 {
+		start := time.Now()
+		_, err := service.GetFibonacci(context.Background(), 0, s.warmUpIndex, s.warmUpTimeout, s.rdb)
+		if err != nil {
+			slog.Warn("warm-up incomplete", "error", err)
+		} else {
+			slog.Info("warm-up completed", "index", s.warmUpIndex, "duration", time.Since(start))
+		}
+	}
 
-	wg.Add(2)
+	s.health.WarmedUp()
+}
 
-	go func(wg *sync.WaitGroup) {
-		defer wg.Done()
-		err := s.http.Start()
+// newQuotaManager создает quota.Manager по конфигурации cfg.
+func newQuotaManager(cfg config.QuotaConfig, rdb *rds.Client) (*quota.Manager, error) {
+	var store quota.Store
+	if cfg.UseRedis {
+		store = quota.NewRedisStore(rdb.Cl)
+	} else {
+		var err error
+		store, err = quota.NewFileStore(cfg.StoreFile)
 		if err != nil {
-			log.Fatal(err)
+			return nil, fmt.Errorf("quota store: %w", err)
 		}
-	}(&wg)
+	}
+
+	def, clients := quotaLimits(cfg)
+	return quota.New(store, def, clients), nil
+}
 
-	go func(wg *sync.WaitGroup) {
-		defer wg.Done()
-		err := s.grpc.Start()
+// quotaLimits возвращает квоты по умолчанию и квоты клиентов из конфигурации cfg.
+func quotaLimits(cfg config.QuotaConfig) (quota.Limits, map[string]quota.Limits) {
+	clients := make(map[string]quota.Limits, len(cfg.Clients))
+	for name, l := range cfg.Clients {
+		clients[name] = parseQuotaLimits(l)
+	}
+
+	return parseQuotaLimits(cfg.Default), clients
+}
+
+func parseQuotaLimits(cfg config.QuotaLimitsConfig) quota.Limits {
+	return quota.Limits{
+		DailyCompute:   cfg.DailyComputeTime,
+		MonthlyCompute: cfg.MonthlyComputeTime,
+		DailyBytes:     cfg.DailyBytes,
+		MonthlyBytes:   cfg.MonthlyBytes,
+	}
+}
+
+// newTLSConfig создает *tls.Config, перечитывающий сертификаты с диска при их изменении. Если сертификат сервера не
+// задан, возвращает nil.
+func newTLSConfig(certFile, keyFile, caFile string, requireClientCert bool, nextProtos ...string) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+
+	r, err := tlsconfig.New(certFile, keyFile, caFile, requireClientCert)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Config(nextProtos...), nil
+}
+
+// newGuard создает auth.Guard по конфигурации cfg. API ключи проверяются раньше JWT.
+func newGuard(cfg config.AuthConfig) (*auth.Guard, error) {
+	keys := make([]auth.APIKey, 0, len(cfg.APIKeys))
+	for _, k := range cfg.APIKeys {
+		keys = append(keys, auth.APIKey{Key: k.Key, Name: k.Name, Scopes: k.Scopes})
+	}
+
+	chain := auth.Chain{auth.NewAPIKeys(keys)}
+
+	if cfg.JWT.HMACSecret != "" || cfg.JWT.RSAPublicKey != "" {
+		j, err := auth.NewJWT(cfg.JWT.HMACSecret, cfg.JWT.RSAPublicKey, cfg.JWT.Issuer, cfg.JWT.Audience)
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
-	}(&wg)
+		chain = append(chain, j)
+	}
 
-	wg.Wait()
+	return &auth.Guard{
+		Authenticator:  chain,
+		AllowAnonymous: cfg.AllowAnonymous,
+		HugeIndex:      cfg.HugeIndex,
+	}, nil
+}
+
+// newRateLimiter создает ratelimit.Limiter по конфигурации cfg. При cfg.UseRedis лимиты хранятся в Redis и являются
+// общими для всех экземпляров сервиса.
+func newRateLimiter(cfg config.RateLimitConfig, rdb *rds.Client) *ratelimit.Limiter {
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.UseRedis {
+		store = ratelimit.NewRedisStore(rdb.Cl)
+	}
+
+	def, endpoints := rateLimits(cfg)
+	return ratelimit.New(store, def, endpoints)
+}
+
+// rateLimits возвращает лимит по умолчанию и лимиты эндпоинтов из конфигурации cfg.
+func rateLimits(cfg config.RateLimitConfig) (ratelimit.Limit, map[string]ratelimit.Limit) {
+	endpoints := make(map[string]ratelimit.Limit, len(cfg.Endpoints))
+	for name, l := range cfg.Endpoints {
+		endpoints[name] = ratelimit.Limit{Rate: l.Rate, Burst: l.Burst}
+	}
+
+	return ratelimit.Limit{Rate: cfg.Rate, Burst: cfg.Burst}, endpoints
 }
 
+// Start запускает включенные серверы и блокируется до завершения остановки сервиса. Если какой-либо из серверов не
+// удалось запустить, сервис останавливается, а Start возвращает ошибку запуска.
+func (s *Sever) Start() error {
+	var starts []func() error
+	if s.http != nil {
+		starts = append(starts, s.http.Start)
+	}
+	if s.grpc != nil {
+		starts = append(starts, s.grpc.Start)
+	}
+	if s.admin != nil {
+		starts = append(starts, s.admin.Start)
+	}
+
+	go s.warmUp()
+
+	errCh := make(chan error, len(starts))
+	for _, start := range starts {
+		go func(start func() error) {
+			errCh <- start()
+		}(start)
+	}
+
+	var startErr error
+	for range starts {
+		if err := <-errCh; err != nil && startErr == nil {
+			startErr = err
+			go s.Stop()
+		}
+	}
+
+	<-s.stopped
+	return startErr
+}
+
+// Stop останавливает сервис: проверка готовности начинает завершаться неудачей, через drainDelay серверы прекращают
+// прием новых запросов и дожидаются завершения обрабатываемых, после чего дописываются начатые записи в Redis и
+// сохраняются квоты. Ожидание ограничено gracePeriod. Повторные вызовы Stop ничего не делают.
 func (s *Sever) Stop() {
-	s.grpc.Stop()
+	s.stopOnce.Do(s.stop)
+}
 
-	err := s.http.Stop()
-	if err != nil {
-		log.Fatal(err)
+func (s *Sever) stop() {
+	defer close(s.stopped)
+
+	s.health.Drain()
+	slog.Info("draining", "delay", s.drainDelay, "grace_period", s.gracePeriod)
+	time.Sleep(s.drainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.gracePeriod)
+	defer cancel()
+
+	var wg sync.WaitGroup
+
+	if s.grpc != nil {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			s.grpc.Stop(ctx)
+		}()
+	}
+
+	if s.http != nil {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			if err := s.http.Stop(ctx); err != nil {
+				slog.Error("http server stop error", "error", err)
+			}
+		}()
+	}
+
+	if s.admin != nil {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			if err := s.admin.Stop(ctx); err != nil {
+				slog.Error("admin server stop error", "error", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if s.quota != nil {
+		if err := s.quota.Close(); err != nil {
+			slog.Error("quota store close error", "error", err)
+		}
+	}
+
+	if err := s.rdb.Close(ctx); err != nil {
+		slog.Error("redis close error", "error", err)
+	}
+
+	if s.shutdownTracing != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+
+		if err := s.shutdownTracing(ctx); err != nil {
+			slog.Error("tracing shutdown error", "error", err)
+		}
 	}
+
+	slog.Info("server stopped")
 }
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	rds "github.com/dmitrykharchenko95/fibonacci/internal/rds"
+	"github.com/dmitrykharchenko95/fibonacci/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// MaxBatch - наибольшее количество диапазонов в одном пакетном запросе.
+const MaxBatch = 100
+
+// Range - диапазон порядковых номеров чисел Фибоначчи от From до To включительно.
+type Range struct {
+	From, To int
+}
+
+// BatchResult - числа Фибоначчи, вычисленные для одного диапазона пакета. Если до истечения времени вычислена только
+// часть чисел, Values содержит вычисленные числа, а Err - ошибку вида "timeout exit: returned <N> values from <M>".
+// Items содержит те же числа вместе с источником значения и временем его получения.
+type BatchResult struct {
+	Values []string
+	Items  []Item
+	Err    error
+}
+
+// Len возвращает количество порядковых номеров в диапазоне r без переполнения int. Для диапазона из всех значений
+// int возвращается math.MaxUint64.
+func (r Range) Len() uint64 {
+	n := uint64(r.To) - uint64(r.From)
+	if n == math.MaxUint64 {
+		return n
+	}
+	return n + 1
+}
+
+// Total возвращает общее количество порядковых номеров в диапазонах ranges, ограниченное math.MaxUint64. Номера
+// перекрывающихся диапазонов учитываются один раз.
+func Total(ranges []Range) uint64 {
+	var total uint64
+	for _, r := range Merge(ranges) {
+		if total > math.MaxUint64-r.Len() {
+			return math.MaxUint64
+		}
+		total += r.Len()
+	}
+	return total
+}
+
+// Merge возвращает диапазоны ranges, упорядоченные по From, объединив перекрывающиеся и смежные диапазоны. Диапазоны
+// не должны быть пустыми (From <= To).
+func Merge(ranges []Range) []Range {
+	sorted := make([]Range, len(ranges))
+	copy(sorted, ranges)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].From < sorted[j].From })
+
+	merged := make([]Range, 0, len(sorted))
+	for _, r := range sorted {
+		// Разность r.From-To равна 1 только для смежных диапазонов, в отличие от To+1 она не переполняется при To,
+		// равном math.MaxInt.
+		if last := len(merged) - 1; last >= 0 && (r.From <= merged[last].To || r.From-merged[last].To == 1) {
+			if r.To > merged[last].To {
+				merged[last].To = r.To
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+
+	return merged
+}
+
+// GetBatch вычисляет числа Фибоначчи для диапазонов ranges и возвращает результаты в том же порядке. Перекрывающиеся
+// и смежные диапазоны объединяются (см. Merge), поэтому каждое число вычисляется (или читается из Redis) один раз.
+// Через аргумент timeout передается общее время вычисления всех диапазонов: диапазоны, не вычисленные полностью до его
+// истечения, возвращаются с ошибкой ErrTimeoutExit. Диапазоны не должны быть пустыми (From <= To).
+func GetBatch(ctx context.Context, ranges []Range, timeout time.Duration, rdb *rds.Client) []BatchResult {
+	ctx, span := tracing.Start(ctx, "service.GetBatch")
+	span.SetAttributes(attribute.Int("fibonacci.ranges", len(ranges)))
+	defer span.End()
+
+	var (
+		deadline = time.Now().Add(timeout)
+		merged   = Merge(ranges)
+		computed = make([][]Item, len(merged))
+	)
+	for i, m := range merged {
+		left := time.Until(deadline)
+		if left <= 0 || ctx.Err() != nil {
+			break
+		}
+		computed[i], _ = GetFibonacciItems(ctx, m.From, m.To, left, rdb)
+	}
+
+	res := make([]BatchResult, len(ranges))
+	for i, r := range ranges {
+		j := sort.Search(len(merged), func(j int) bool { return merged[j].To >= r.From })
+		data, start, n := computed[j], r.From-merged[j].From, r.To-r.From+1
+
+		if start+n <= len(data) {
+			res[i].Items = data[start : start+n]
+			res[i].Values = Values(res[i].Items)
+			continue
+		}
+
+		res[i].Items = []Item{}
+		if start < len(data) {
+			res[i].Items = data[start:]
+		}
+		res[i].Values = Values(res[i].Items)
+		res[i].Err = fmt.Errorf("%w: returned %v values from %v", ErrTimeoutExit, len(res[i].Values), n)
+	}
+
+	return res
+}
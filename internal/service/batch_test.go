@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges []Range
+		want   []Range
+	}{
+		{
+			name:   "disjoint",
+			ranges: []Range{{10, 12}, {0, 2}},
+			want:   []Range{{0, 2}, {10, 12}},
+		},
+		{
+			name:   "overlapping and adjacent",
+			ranges: []Range{{5, 8}, {0, 3}, {2, 4}, {7, 7}, {-3, -1}},
+			want:   []Range{{-3, 8}},
+		},
+		{
+			name:   "max int",
+			ranges: []Range{{math.MaxInt, math.MaxInt}, {math.MaxInt - 1, math.MaxInt}, {math.MinInt, math.MinInt}},
+			want:   []Range{{math.MinInt, math.MinInt}, {math.MaxInt - 1, math.MaxInt}},
+		},
+		{
+			name:   "duplicates",
+			ranges: []Range{{5, 5}, {5, 5}, {1, 1}},
+			want:   []Range{{1, 1}, {5, 5}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Merge(tt.ranges); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTotal(t *testing.T) {
+	if got := Total([]Range{{0, 3}, {2, 5}, {10, 10}}); got != 7 {
+		t.Errorf("Total() got = %v, want 7", got)
+	}
+	if got := (Range{math.MinInt, math.MaxInt}).Len(); got != math.MaxUint64 {
+		t.Errorf("Len() got = %v, want %v", got, uint64(math.MaxUint64))
+	}
+	if got := Total([]Range{{math.MinInt, -1}, {1, math.MaxInt}}); got != math.MaxUint64 {
+		t.Errorf("Total() got = %v, want %v", got, uint64(math.MaxUint64))
+	}
+}
+
+func TestGetBatch(t *testing.T) {
+	got := GetBatch(context.Background(), []Range{{8, 10}, {-2, 1}, {10, 10}, {0, 3}}, time.Second*3, rdb)
+	want := []BatchResult{
+		{Values: []string{"21", "34", "55"}},
+		{Values: []string{"-1", "1", "0", "1"}},
+		{Values: []string{"55"}},
+		{Values: []string{"0", "1", "1", "2"}},
+	}
+	for i := range got {
+		if !reflect.DeepEqual(got[i].Values, want[i].Values) || got[i].Err != nil {
+			t.Errorf("GetBatch() got = %v, want %v", got[i], want[i])
+		}
+		if !reflect.DeepEqual(Values(got[i].Items), got[i].Values) {
+			t.Errorf("GetBatch() got items %v, want values %v", got[i].Items, got[i].Values)
+		}
+	}
+	if got[1].Items[0].Index != -2 {
+		t.Errorf("GetBatch() got first index %v, want -2", got[1].Items[0].Index)
+	}
+
+	got = GetBatch(context.Background(), []Range{{0, 2}, {100000000, 100000000}}, time.Millisecond*100, rdb)
+	if !reflect.DeepEqual(got[0].Values, []string{"0", "1", "1"}) || got[0].Err != nil {
+		t.Errorf("GetBatch() got = %v, want values before timeout", got[0])
+	}
+	if len(got[1].Values) != 0 || !errors.Is(got[1].Err, ErrTimeoutExit) {
+		t.Errorf("GetBatch() got = %v, want timeout exit", got[1])
+	}
+}
@@ -4,20 +4,55 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/big"
+	"strconv"
+	"sync/atomic"
 	"time"
 
+	"github.com/dmitrykharchenko95/fibonacci/internal/metrics"
 	rds "github.com/dmitrykharchenko95/fibonacci/internal/rds"
+	"github.com/dmitrykharchenko95/fibonacci/internal/tracing"
 	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 var (
 	ErrTimeoutExit = errors.New("timeout exit")
-	redisAtWork    = 0
-	UseRedis       = true
+	redisAtWork    atomic.Int64
+	useRedis       atomic.Bool
 )
 
+func init() {
+	useRedis.Store(true)
+	metrics.RedisEnabledFunc = UseRedis
+}
+
+// UseRedis сообщает, используется ли Redis для кэширования вычисленных значений.
+func UseRedis() bool {
+	return useRedis.Load()
+}
+
+// SetUseRedis включает или отключает кэширование в Redis. При включении сбрасывается счетчик ошибок Redis.
+func SetUseRedis(v bool) {
+	if v {
+		redisAtWork.Store(0)
+	}
+	useRedis.Store(v)
+}
+
+// redisFailed учитывает ошибку операции op с Redis и отключает кэширование после rdb.MaxErrors() ошибок.
+func redisFailed(ctx context.Context, rdb *rds.Client, op string, err error) {
+	metrics.RedisErrors.WithLabelValues(op).Inc()
+
+	n := redisAtWork.Add(1)
+	slog.ErrorContext(ctx, "redis "+op+" error", "count", n, "error", err)
+	if n >= int64(rdb.MaxErrors()) && useRedis.CompareAndSwap(true, false) {
+		slog.WarnContext(ctx, "redis disabled")
+	}
+}
+
 // GetFibonacci при успешном завершении возвращает срез чисел Фибоначчи, форматированных в строки, с порядковыми
 // номерами от x до y и ошибку nil.Через аргумент timeout передается максимальное время работы функции. После истечения
 // timeout функция вернет срез чисел Фибоначчи, которые успела вычислить, и ошибку вида
@@ -25,82 +60,112 @@ var (
 // N - количество вычисленных чисел Фибоначчи;
 // M - ожидаемое количество чисел Фибоначчи.
 // При возникновении maxRedisErrors ошибок в работе Redis GetFibonacci производит вычисление каждого значения через
-// функцию fibonacci
-func GetFibonacci(x, y int, timeout time.Duration, rdb *rds.Client) ([]string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+// функцию fibonacci. Вычисление прерывается также при отмене ctx, спан вычисления создается как дочерний по отношению к
+// спану из ctx.
+func GetFibonacci(ctx context.Context, x, y int, timeout time.Duration, rdb *rds.Client) ([]string, error) {
+	items, err := GetFibonacciItems(ctx, x, y, timeout, rdb)
+	return Values(items), err
+}
+
+// GetFibonacciItems работает аналогично GetFibonacci, но возвращает числа вместе с источником значения и временем его
+// получения.
+func GetFibonacciItems(ctx context.Context, x, y int, timeout time.Duration, rdb *rds.Client) ([]Item, error) {
+	ctx, span := tracing.Start(ctx, "service.GetFibonacci")
+	span.SetAttributes(attribute.Int("fibonacci.x", x), attribute.Int("fibonacci.y", y))
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	metrics.ComputeInFlight.Inc()
+	defer metrics.ComputeInFlight.Dec()
+
+	start := time.Now()
+	defer func() {
+		metrics.ComputeDuration.WithLabelValues(metrics.IndexSize(x, y)).Observe(time.Since(start).Seconds())
+	}()
+
 	var (
-		res    = make([]string, 0, y-x)
+		res    = make([]Item, 0, min(uint64(y)-uint64(x), MaxIndices)+1)
 		stopCh = make(chan struct{})
 	)
 
 	for i := x; i <= y; i++ {
-		I := big.NewInt(int64(i))
-		var num = new(big.Int)
-
-		if UseRedis && rdb.MaxErrors != 0 {
-			val, err := rdb.Cl.Get(ctx, I.Text(10)).Result()
-			switch {
-			case errors.Is(err, redis.Nil):
-				num = fibonacci(ctx, I, stopCh)
-				err = rdb.Cl.Set(ctx, I.Text(10), num.Text(10), rdb.Expiration).Err()
-				if err != nil {
-					redisAtWork += 1
-					if redisAtWork >= rdb.MaxErrors {
-						UseRedis = false
-						log.Println("Redis disabled")
-					}
-					log.Printf("Redis Set error #%v: %v\n", redisAtWork+1, err)
-				} /*else { 							// раскомментировать для логирования при добавлении значения в Redis
-					log.Printf("val %v with key %v set in Redis", num, i)
-				}*/
-			case err != nil:
-				log.Printf("Redis Get error #%v: %v\n", redisAtWork+1, err)
-				redisAtWork += 1
-				if redisAtWork >= rdb.MaxErrors {
-					UseRedis = false
-					log.Println("Redis disabled")
-				}
-				num = fibonacci(ctx, I, stopCh)
-			default:
-				ok := true
-				num, ok = num.SetString(val, 10)
-				if !ok {
-					log.Printf("wrong value with key '%v' in Redis\n", I.Text(10))
-					num = fibonacci(ctx, I, stopCh)
-				} /*else {							// раскомментировать для логирования при получении значения из Redis
-					log.Printf("val %v with key %v got from Redis", num, i)
-				}*/
-			}
-		} else {
-			num = fibonacci(ctx, I, stopCh)
-		}
+		begin := time.Now()
+		num, src := cached(ctx, rdb, i, stopCh, fibonacci)
 
 		select {
 		case <-stopCh:
-			log.Printf("timeout exit: returned %v values from %v\n", len(res), y-x+1)
+			metrics.Timeouts.Inc()
+			span.SetStatus(codes.Error, ErrTimeoutExit.Error())
+			slog.WarnContext(ctx, "timeout exit", "returned", len(res), "requested", y-x+1)
 			return res, fmt.Errorf("%w: returned %v values from %v", ErrTimeoutExit, len(res), y-x+1)
 		default:
-			res = append(res, num.Text(10))
+			res = append(res, Item{Index: i, Value: num.Text(10), Source: src, Duration: time.Since(begin)})
 		}
 	}
+	span.SetAttributes(attribute.Int("fibonacci.count", len(res)))
 	return res, nil
 }
 
+// cached возвращает число Фибоначчи с порядковым номером i из Redis, а при его отсутствии вычисляет функцией calc и
+// сохраняет в Redis. Если Redis не используется, число вычисляется функцией calc. Прерванное через stopCh вычисление
+// в Redis не сохраняется. Второе значение - источник числа.
+func cached(ctx context.Context, rdb *rds.Client, i int, stopCh chan struct{},
+	calc func(context.Context, *big.Int, chan struct{}) *big.Int) (*big.Int, Source) {
+	I, key := big.NewInt(int64(i)), strconv.Itoa(i)
+	if !UseRedis() || rdb.MaxErrors() == 0 {
+		return calc(ctx, I, stopCh), SourceComputed
+	}
+
+	val, err := rdb.Cl.Get(ctx, key).Result()
+	switch {
+	case errors.Is(err, redis.Nil):
+		metrics.RedisMisses.Inc()
+		num := calc(ctx, I, stopCh)
+		if interrupted(stopCh) {
+			return num, SourceComputed
+		}
+		if err := rdb.Set(ctx, key, num.Text(10)); err != nil {
+			redisFailed(ctx, rdb, "set", err)
+		} else {
+			slog.DebugContext(ctx, "value set in redis", "key", i)
+		}
+		return num, SourceComputed
+	case err != nil:
+		redisFailed(ctx, rdb, "get", err)
+		return calc(ctx, I, stopCh), SourceComputed
+	}
+
+	metrics.RedisHits.Inc()
+	num, ok := new(big.Int).SetString(val, 10)
+	if !ok {
+		slog.WarnContext(ctx, "wrong value in redis", "key", key)
+		return calc(ctx, I, stopCh), SourceComputed
+	}
+	slog.DebugContext(ctx, "value got from redis", "key", i)
+	return num, SourceRedis
+}
+
+// interrupted сообщает, было ли прервано вычисление (закрыт ли stopCh).
+func interrupted(stopCh chan struct{}) bool {
+	select {
+	case <-stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
 // fibonacci вычисляет число Фибоначчи под порядковым номером n. Выполнение функции fibonacci можно прервать через
-// ctx. При преждевременном завершении функции через ctx закрывается сигнальный канал stopCh.
+// ctx. При преждевременном завершении функции через ctx закрывается сигнальный канал stopCh. Аргумент n не изменяется.
 func fibonacci(ctx context.Context, n *big.Int, stopCh chan struct{}) *big.Int {
-	negative := false
+	negative := n.Sign() < 0
+	n = new(big.Int).Abs(n)
 
 	f2 := big.NewInt(0)
 	f1 := big.NewInt(1)
 
-	if n.Sign() < 0 {
-		n.Mul(n, big.NewInt(-1))
-		negative = true
-	}
-
 	switch {
 	case n.Cmp(big.NewInt(0)) == 0:
 		return f2
@@ -12,15 +12,11 @@ import (
 	"github.com/go-redis/redis/v8"
 )
 
-var rdb = &rds.Client{
-	Cl: redis.NewClient(&redis.Options{
-		Addr:     net.JoinHostPort("localhost", "6379"),
-		Password: "",
-		DB:       0,
-	}),
-	Expiration: time.Hour,
-	MaxErrors:  0,
-}
+var rdb = rds.NewClient(redis.NewClient(&redis.Options{
+	Addr:     net.JoinHostPort("localhost", "6379"),
+	Password: "",
+	DB:       0,
+}), time.Hour, 0)
 
 func Test_fibonacci(t *testing.T) {
 	type args struct {
@@ -144,7 +140,7 @@ func Test_getFibonacci(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := GetFibonacci(tt.args.x, tt.args.y, tt.args.timeout, rdb)
+			got, err := GetFibonacci(context.Background(), tt.args.x, tt.args.y, tt.args.timeout, rdb)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetFibonacci() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/metrics"
+	rds "github.com/dmitrykharchenko95/fibonacci/internal/rds"
+	"github.com/dmitrykharchenko95/fibonacci/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// MaxIndices - наибольшее количество порядковых номеров в одном запросе множества номеров.
+const MaxIndices = 10000
+
+// Progression возвращает порядковые номера арифметической прогрессии from, from+step, ..., не выходящие за to. При
+// отрицательном step номера убывают. Второе значение false означает, что прогрессия пуста (step = 0 или направление
+// от from к to не совпадает со знаком step) или содержит больше MaxIndices номеров.
+func Progression(from, to, step int) ([]int, bool) {
+	if step == 0 || step > 0 && from > to || step < 0 && from < to {
+		return nil, false
+	}
+
+	diff, st := uint64(to)-uint64(from), uint64(step)
+	if step < 0 {
+		diff, st = uint64(from)-uint64(to), -uint64(step)
+	}
+	if diff/st >= MaxIndices {
+		return nil, false
+	}
+
+	indices := make([]int, diff/st+1)
+	for i := range indices {
+		indices[i] = from + i*step
+	}
+	return indices, true
+}
+
+// GetIndices при успешном завершении возвращает числа Фибоначчи с порядковыми номерами indices в том же порядке,
+// форматированные в строки, и ошибку nil. Каждое число вычисляется методом быстрого удвоения за O(log n) умножений,
+// без вычисления промежуточных чисел; повторяющиеся номера вычисляются один раз. Через аргумент timeout передается
+// максимальное время работы функции: после его истечения функция вернет числа, которые успела вычислить, и ошибку
+// вида "timeout exit: returned <N> values from <M>". Как и GetFibonacci, функция использует Redis для кэширования.
+func GetIndices(ctx context.Context, indices []int, timeout time.Duration, rdb *rds.Client) ([]string, error) {
+	items, err := GetIndicesItems(ctx, indices, timeout, rdb)
+	return Values(items), err
+}
+
+// GetIndicesItems работает аналогично GetIndices, но возвращает числа вместе с источником значения и временем его
+// получения. Для повторяющихся номеров возвращаются сведения о первом получении числа.
+func GetIndicesItems(ctx context.Context, indices []int, timeout time.Duration, rdb *rds.Client) ([]Item, error) {
+	ctx, span := tracing.Start(ctx, "service.GetIndices")
+	span.SetAttributes(attribute.Int("fibonacci.indices", len(indices)))
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	metrics.ComputeInFlight.Inc()
+	defer metrics.ComputeInFlight.Dec()
+
+	lo, hi := bounds(indices)
+	start := time.Now()
+	defer func() {
+		metrics.ComputeDuration.WithLabelValues(metrics.IndexSize(lo, hi)).Observe(time.Since(start).Seconds())
+	}()
+
+	var (
+		res    = make([]Item, 0, len(indices))
+		seen   = make(map[int]Item, len(indices))
+		stopCh = make(chan struct{})
+	)
+	for _, i := range indices {
+		if it, ok := seen[i]; ok {
+			res = append(res, it)
+			continue
+		}
+
+		begin := time.Now()
+		num, src := cached(ctx, rdb, i, stopCh, fibonacciFast)
+		if interrupted(stopCh) {
+			metrics.Timeouts.Inc()
+			span.SetStatus(codes.Error, ErrTimeoutExit.Error())
+			slog.WarnContext(ctx, "timeout exit", "returned", len(res), "requested", len(indices))
+			return res, fmt.Errorf("%w: returned %v values from %v", ErrTimeoutExit, len(res), len(indices))
+		}
+
+		seen[i] = Item{Index: i, Value: num.Text(10), Source: src, Duration: time.Since(begin)}
+		res = append(res, seen[i])
+	}
+
+	span.SetAttributes(attribute.Int("fibonacci.count", len(res)))
+	return res, nil
+}
+
+// bounds возвращает наименьший и наибольший из номеров indices.
+func bounds(indices []int) (int, int) {
+	if len(indices) == 0 {
+		return 0, 0
+	}
+
+	lo, hi := indices[0], indices[0]
+	for _, i := range indices[1:] {
+		if i < lo {
+			lo = i
+		}
+		if i > hi {
+			hi = i
+		}
+	}
+	return lo, hi
+}
+
+// fibonacciFast вычисляет число Фибоначчи под порядковым номером n методом быстрого удвоения:
+// F(2k) = F(k)·(2F(k+1) - F(k)), F(2k+1) = F(k)² + F(k+1)². Контракт совпадает с fibonacci: выполнение можно
+// прервать через ctx, при этом закрывается сигнальный канал stopCh.
+func fibonacciFast(ctx context.Context, n *big.Int, stopCh chan struct{}) *big.Int {
+	k := new(big.Int).Abs(n)
+
+	a, b := big.NewInt(0), big.NewInt(1) // F(0), F(1)
+	for bit := k.BitLen() - 1; bit >= 0; bit-- {
+		select {
+		case <-ctx.Done():
+			close(stopCh)
+			return a
+		default:
+		}
+
+		c := new(big.Int).Lsh(b, 1)
+		c.Sub(c, a).Mul(c, a)
+		d := new(big.Int).Mul(a, a)
+		d.Add(d, new(big.Int).Mul(b, b))
+
+		if k.Bit(bit) == 1 {
+			a, b = d, c.Add(c, d)
+		} else {
+			a, b = c, d
+		}
+	}
+
+	if n.Sign() < 0 && k.Bit(0) == 0 {
+		a.Neg(a)
+	}
+
+	return a
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_fibonacciFast(t *testing.T) {
+	for n := int64(-100); n <= 100; n++ {
+		want := fibonacci(context.Background(), big.NewInt(n), make(chan struct{}))
+		got := fibonacciFast(context.Background(), big.NewInt(n), make(chan struct{}))
+		if got.Cmp(want) != 0 {
+			t.Errorf("fibonacciFast(%v) got = %v, want %v", n, got, want)
+		}
+	}
+
+	want := fibonacci(context.Background(), big.NewInt(10001), make(chan struct{}))
+	if got := fibonacciFast(context.Background(), big.NewInt(10001), make(chan struct{})); got.Cmp(want) != 0 {
+		t.Errorf("fibonacciFast(10001) got = %v, want %v", got, want)
+	}
+}
+
+func TestProgression(t *testing.T) {
+	tests := []struct {
+		name           string
+		from, to, step int
+		want           []int
+		wantOK         bool
+	}{
+		{name: "ascending", from: 0, to: 3000, step: 1000, want: []int{0, 1000, 2000, 3000}, wantOK: true},
+		{name: "offset", from: 5, to: 30, step: 10, want: []int{5, 15, 25}, wantOK: true},
+		{name: "descending", from: 10, to: -5, step: -5, want: []int{10, 5, 0, -5}, wantOK: true},
+		{name: "single", from: 7, to: 7, step: -1, want: []int{7}, wantOK: true},
+		{name: "zero step", from: 0, to: 10, step: 0},
+		{name: "wrong direction", from: 0, to: 10, step: -1},
+		{name: "too many", from: 0, to: MaxIndices, step: 1},
+		{name: "overflow", from: math.MinInt, to: math.MaxInt, step: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Progression(tt.from, tt.to, tt.step)
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Progression() got = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGetIndices(t *testing.T) {
+	got, err := GetIndices(context.Background(), []int{10, -6, 100, 10, 0}, time.Second*3, rdb)
+	if err != nil {
+		t.Fatalf("GetIndices() error = %v", err)
+	}
+	want := []string{"55", "-8", "354224848179261915075", "55", "0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetIndices() got = %v, want %v", got, want)
+	}
+
+	got, err = GetIndices(context.Background(), []int{1, 1000000000}, time.Millisecond*100, rdb)
+	if !errors.Is(err, ErrTimeoutExit) || !reflect.DeepEqual(got, []string{"1"}) {
+		t.Errorf("GetIndices() got = %v, %v, want values before timeout exit", got, err)
+	}
+}
+
+func TestCacheKeys(t *testing.T) {
+	rdb, data := newTestRedis(t)
+
+	got, err := GetFibonacci(context.Background(), -4, -4, time.Second, rdb)
+	if err != nil || !reflect.DeepEqual(got, []string{"-3"}) {
+		t.Fatalf("GetFibonacci() got = %v, %v, want [-3]", got, err)
+	}
+	if data["-4"] != "-3" || data["4"] != "" {
+		t.Errorf("GetFibonacci() cached %v, want only -4: -3", data)
+	}
+
+	items, err := GetIndicesItems(context.Background(), []int{4, -4}, time.Second, rdb)
+	if err != nil {
+		t.Fatalf("GetIndicesItems() error = %v", err)
+	}
+	if items[0].Value != "3" || items[0].Source != SourceComputed {
+		t.Errorf("GetIndicesItems() got %+v, want computed 3", items[0])
+	}
+	if items[1].Value != "-3" || items[1].Source != SourceRedis {
+		t.Errorf("GetIndicesItems() got %+v, want -3 from redis", items[1])
+	}
+}
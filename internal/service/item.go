@@ -0,0 +1,50 @@
+package service
+
+import (
+	"strings"
+	"time"
+)
+
+// Source - источник значения числа Фибоначчи.
+type Source string
+
+const (
+	// SourceComputed - значение вычислено при обработке запроса.
+	SourceComputed Source = "computed"
+	// SourceRedis - значение получено из кэша Redis.
+	SourceRedis Source = "redis"
+)
+
+// Item - число Фибоначчи Value с порядковым номером Index. Source - источник значения, Duration - время его получения
+// (вычисления или чтения из кэша).
+type Item struct {
+	Index    int
+	Value    string
+	Source   Source
+	Duration time.Duration
+}
+
+// Digits возвращает количество десятичных цифр числа без учета знака.
+func (it Item) Digits() int {
+	return len(strings.TrimPrefix(it.Value, "-"))
+}
+
+// Values возвращает значения чисел items в том же порядке.
+func Values(items []Item) []string {
+	values := make([]string, len(items))
+	for i, it := range items {
+		values[i] = it.Value
+	}
+	return values
+}
+
+// CacheHits возвращает количество чисел items, полученных из кэша.
+func CacheHits(items []Item) int {
+	hits := 0
+	for _, it := range items {
+		if it.Source != SourceComputed {
+			hits++
+		}
+	}
+	return hits
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestItem(t *testing.T) {
+	items := []Item{
+		{Index: -6, Value: "-8", Source: SourceComputed},
+		{Index: 12, Value: "144", Source: SourceRedis},
+	}
+
+	if got := items[0].Digits(); got != 1 {
+		t.Errorf("Digits() got = %v, want 1", got)
+	}
+	if got := items[1].Digits(); got != 3 {
+		t.Errorf("Digits() got = %v, want 3", got)
+	}
+	if got := Values(items); !reflect.DeepEqual(got, []string{"-8", "144"}) {
+		t.Errorf("Values() got = %v, want [-8 144]", got)
+	}
+	if got := CacheHits(items); got != 1 {
+		t.Errorf("CacheHits() got = %v, want 1", got)
+	}
+}
+
+func TestGetItems(t *testing.T) {
+	items, err := GetFibonacciItems(context.Background(), 8, 10, time.Second, rdb)
+	if err != nil {
+		t.Fatalf("GetFibonacciItems() error = %v", err)
+	}
+	for k, it := range items {
+		if it.Index != 8+k || it.Source != SourceComputed {
+			t.Errorf("GetFibonacciItems() got item %+v, want index %v computed", it, 8+k)
+		}
+	}
+
+	items, err = GetIndicesItems(context.Background(), []int{100, 5, 100}, time.Second, rdb)
+	if err != nil {
+		t.Fatalf("GetIndicesItems() error = %v", err)
+	}
+	if got := []int{items[0].Index, items[1].Index, items[2].Index}; !reflect.DeepEqual(got, []int{100, 5, 100}) {
+		t.Errorf("GetIndicesItems() got indices %v, want [100 5 100]", got)
+	}
+	if items[0] != items[2] {
+		t.Errorf("GetIndicesItems() got %+v for repeated index, want %+v", items[2], items[0])
+	}
+	if items[1].Value != "5" || items[1].Source != SourceComputed {
+		t.Errorf("GetIndicesItems() got %+v, want computed 5", items[1])
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dmitrykharchenko95/fibonacci/internal/rds"
+	"github.com/go-redis/redis/v8"
+)
+
+// newTestRedis возвращает клиента Redis, подключенного к серверу в памяти, поддерживающему команды GET и SET.
+func newTestRedis(t *testing.T) (*rds.Client, map[string]string) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	var (
+		mu   sync.Mutex
+		data = map[string]string{}
+	)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveRedis(conn, &mu, data)
+		}
+	}()
+
+	cl := redis.NewClient(&redis.Options{Addr: l.Addr().String()})
+	t.Cleanup(func() { cl.Close() })
+	return rds.NewClient(cl, time.Hour, 10), data
+}
+
+func serveRedis(conn net.Conn, mu *sync.Mutex, data map[string]string) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		mu.Lock()
+		reply := "+OK\r\n"
+		switch strings.ToUpper(args[0]) {
+		case "GET":
+			reply = "$-1\r\n"
+			if val, ok := data[args[1]]; ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(val), val)
+			}
+		case "SET":
+			data[args[1]] = args[2]
+		}
+		mu.Unlock()
+
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand читает команду Redis в формате RESP: массив строк.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	n, err := readLength(r, '*')
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		size, err := readLength(r, '$')
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func readLength(r *bufio.Reader, prefix byte) (int, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+	if len(line) < 3 || line[0] != prefix {
+		return 0, fmt.Errorf("unexpected line %q", line)
+	}
+	return strconv.Atoi(strings.TrimRight(line[1:], "\r\n"))
+}
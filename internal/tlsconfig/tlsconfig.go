@@ -0,0 +1,185 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// checkInterval - минимальный интервал между проверками изменения файлов сертификатов.
+const checkInterval = 10 * time.Second
+
+// Reloader загружает сертификат сервера и сертификаты CA клиентов с диска и перечитывает их при изменении файлов, что
+// позволяет обновлять сертификаты без перезапуска сервиса.
+type Reloader struct {
+	certFile          string
+	keyFile           string
+	caFile            string
+	requireClientCert bool
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	pool     *x509.CertPool
+	modTimes [3]time.Time
+	checked  time.Time
+	now      func() time.Time
+}
+
+// New создает новый объект типа Reloader. Если задан caFile, сервер проверяет сертификаты клиентов, подписанные этим
+// CA; при requireClientCert клиенты без сертификата не допускаются (mTLS).
+func New(certFile, keyFile, caFile string, requireClientCert bool) (*Reloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("tls: both cert and key files are required")
+	}
+
+	if requireClientCert && caFile == "" {
+		return nil, errors.New("tls: client certificate requirement needs a CA file")
+	}
+
+	r := &Reloader{
+		certFile:          certFile,
+		keyFile:           keyFile,
+		caFile:            caFile,
+		requireClientCert: requireClientCert,
+		now:               time.Now,
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Config возвращает *tls.Config, использующий актуальные сертификаты Reloader. nextProtos задает поддерживаемые
+// протоколы ALPN.
+func (r *Reloader) Config(nextProtos ...string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: nextProtos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool := r.current()
+
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   nextProtos,
+				Certificates: []tls.Certificate{*cert},
+			}
+
+			if pool != nil {
+				cfg.ClientCAs = pool
+				cfg.ClientAuth = tls.VerifyClientCertIfGiven
+				if r.requireClientCert {
+					cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				}
+			}
+
+			return cfg, nil
+		},
+	}
+}
+
+// current возвращает актуальные сертификаты, перечитывая их с диска при изменении файлов. При ошибке чтения
+// продолжают использоваться ранее загруженные сертификаты.
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now := r.now(); now.Sub(r.checked) >= checkInterval {
+		r.checked = now
+		if r.modified() {
+			if err := r.load(); err != nil {
+				slog.Error("tls certificates reload failed", "error", err)
+			} else {
+				slog.Info("tls certificates reloaded", "file", r.certFile)
+			}
+		}
+	}
+
+	return r.cert, r.pool
+}
+
+// modified сообщает, изменились ли файлы сертификатов с момента последней загрузки.
+func (r *Reloader) modified() bool {
+	for i, name := range r.files() {
+		if name == "" {
+			continue
+		}
+
+		fi, err := os.Stat(name)
+		if err != nil {
+			continue
+		}
+
+		if !fi.ModTime().Equal(r.modTimes[i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Reloader) load() error {
+	var modTimes [3]time.Time
+	for i, name := range r.files() {
+		if name == "" {
+			continue
+		}
+
+		fi, err := os.Stat(name)
+		if err != nil {
+			return fmt.Errorf("tls: %w", err)
+		}
+		modTimes[i] = fi.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("tls: load key pair: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("tls: read ca file: %w", err)
+		}
+
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("tls: no certificates found in %v", r.caFile)
+		}
+	}
+
+	r.cert, r.pool, r.modTimes = &cert, pool, modTimes
+
+	return nil
+}
+
+func (r *Reloader) files() [3]string {
+	return [3]string{r.certFile, r.keyFile, r.caFile}
+}
+
+// PeerName возвращает имя клиента из проверенного клиентского сертификата: Common Name или первое DNS имя. Если клиент
+// не предъявил сертификат, возвращается пустая строка.
+func PeerName(cs *tls.ConnectionState) string {
+	if cs == nil || len(cs.VerifiedChains) == 0 || len(cs.VerifiedChains[0]) == 0 {
+		return ""
+	}
+
+	leaf := cs.VerifiedChains[0][0]
+	if leaf.Subject.CommonName != "" {
+		return leaf.Subject.CommonName
+	}
+
+	if len(leaf.DNSNames) > 0 {
+		return leaf.DNSNames[0]
+	}
+
+	return ""
+}
@@ -0,0 +1,156 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type keyPair struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newKeyPair(t *testing.T, cn string, serial int64, parent *keyPair) *keyPair {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+	}
+
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &keyPair{cert: cert, key: key, der: der}
+}
+
+func (kp *keyPair) write(t *testing.T, certFile, keyFile string) {
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: kp.der}), 0o600))
+
+	if keyFile != "" {
+		der, err := x509.MarshalECPrivateKey(kp.key)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600))
+	}
+}
+
+func (kp *keyPair) tls() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{kp.der}, PrivateKey: kp.key}
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	caFile := filepath.Join(dir, "ca.crt")
+
+	ca := newKeyPair(t, "ca", 1, nil)
+	ca.write(t, caFile, "")
+	newKeyPair(t, "server", 2, ca).write(t, certFile, keyFile)
+	client := newKeyPair(t, "client", 3, ca)
+
+	r, err := New(certFile, keyFile, caFile, true)
+	require.NoError(t, err)
+
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	lsn, err := tls.Listen("tcp", "127.0.0.1:0", r.Config())
+	require.NoError(t, err)
+	defer lsn.Close()
+
+	peers := make(chan string, 1)
+	go func() {
+		for {
+			conn, err := lsn.Accept()
+			if err != nil {
+				return
+			}
+			tc := conn.(*tls.Conn)
+			_ = tc.SetDeadline(time.Now().Add(5 * time.Second))
+			if err := tc.Handshake(); err == nil {
+				_, _ = tc.Write([]byte{1})
+			}
+			cs := tc.ConnectionState()
+			peers <- PeerName(&cs)
+			_ = conn.Close()
+		}
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	dial := func(certs ...tls.Certificate) (*big.Int, error) {
+		conn, err := tls.Dial("tcp", lsn.Addr().String(), &tls.Config{
+			RootCAs:      roots,
+			ServerName:   "localhost",
+			Certificates: certs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+
+		// в TLS 1.3 сервер проверяет сертификат клиента после завершения рукопожатия на стороне клиента
+		_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+		if _, err := conn.Read(make([]byte, 1)); err != nil {
+			return nil, err
+		}
+
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber, nil
+	}
+
+	t.Run("mtls", func(t *testing.T) {
+		serial, err := dial(client.tls())
+		require.NoError(t, err)
+		require.Equal(t, int64(2), serial.Int64())
+		require.Equal(t, "client", <-peers)
+	})
+
+	t.Run("no client cert", func(t *testing.T) {
+		_, err := dial()
+		require.Error(t, err)
+		<-peers
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		newKeyPair(t, "server", 4, ca).write(t, certFile, keyFile)
+		future := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(certFile, future, future))
+		now = now.Add(checkInterval)
+
+		serial, err := dial(client.tls())
+		require.NoError(t, err)
+		require.Equal(t, int64(4), serial.Int64())
+		<-peers
+	})
+}
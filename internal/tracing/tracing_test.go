@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestInit(t *testing.T) {
+	requests := make(chan *coltracepb.ExportTraceServiceRequest, 1)
+
+	// заглушка OTLP/HTTP коллектора
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/traces", r.URL.Path)
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		req := &coltracepb.ExportTraceServiceRequest{}
+		require.NoError(t, proto.Unmarshal(body, req))
+		requests <- req
+	}))
+	defer collector.Close()
+
+	shutdown, err := Init(context.Background(), collector.Listener.Addr().String(), true, "fibonacci-test", 1)
+	require.NoError(t, err)
+
+	ctx, parent := Start(context.Background(), "parent")
+	_, child := Start(ctx, "child")
+	child.End()
+	parent.End()
+
+	require.NoError(t, shutdown(context.Background()))
+
+	req := <-requests
+	require.Len(t, req.ResourceSpans, 1)
+
+	var names []string
+	for _, ils := range req.ResourceSpans[0].InstrumentationLibrarySpans {
+		for _, span := range ils.Spans {
+			names = append(names, span.Name)
+		}
+	}
+	require.ElementsMatch(t, []string{"parent", "child"}, names)
+}